@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkpoint is the state of an unfinished crawl. The pages parsed so far
+// are kept in the partial NDJSON file next to it.
+type checkpoint struct {
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Entries is the listing page as it was when the crawl started.
+	Entries []listingEntry `json:"entries"`
+	// ToFetch lists the ids whose detail page the crawl fetches.
+	ToFetch []int `json:"to_fetch"`
+	// Done lists the ids already parsed and written to the partial file.
+	Done []int `json:"done"`
+}
+
+// checkpointFileName returns the checkpoint of a crawl into jsonFile, e.g.
+// data.checkpoint.json for data.json.
+func checkpointFileName(jsonFile string) string {
+	return strings.TrimSuffix(jsonFile, ".json") + ".checkpoint.json"
+}
+
+// loadCheckpoint reads the checkpoint at path.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &checkpoint{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// remaining returns the entries of the checkpoint still to be fetched.
+func (state *checkpoint) remaining() []listingEntry {
+	done := make(map[int]bool, len(state.Done))
+	for _, id := range state.Done {
+		done[id] = true
+	}
+	toFetch := make(map[int]bool, len(state.ToFetch))
+	for _, id := range state.ToFetch {
+		toFetch[id] = true
+	}
+
+	var entries []listingEntry
+	for _, entry := range state.Entries {
+		if toFetch[entry.Id] && !done[entry.Id] {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// checkpointer saves the checkpoint of a running crawl. It is safe for
+// concurrent use.
+type checkpointer struct {
+	mu    sync.Mutex
+	path  string
+	state *checkpoint
+}
+
+// Done records that the page of id has been written to the partial file.
+func (c *checkpointer) Done(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state.Done = append(c.state.Done, id)
+}
+
+// Save replaces the checkpoint file with the current state.
+func (c *checkpointer) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state.UpdatedAt = time.Now().UTC()
+	sort.Ints(c.state.Done)
+	return writeFileAtomic(c.path, func(w io.Writer) error {
+		return writeJSON(w, c.state)
+	})
+}
+
+// saveEvery saves the checkpoint at every interval until the returned
+// function is called.
+func (c *checkpointer) saveEvery(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := c.Save(); err != nil {
+					log.Printf("Failed to save checkpoint %s: %s", c.path, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// ids returns the ids of entries.
+func ids(entries []listingEntry) []int {
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.Id
+	}
+	return ids
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// runCrawl crawls the listing page and every detail page, then replaces the
+// published dataset once the new files validate.
+func runCrawl(args []string) error {
+	fs := flag.NewFlagSet("crawl", flag.ExitOnError)
+	opts := crawlOptions{}
+	setup := addCrawlFlags(fs, &opts)
+	jsonFile := fs.String("json", jsonFileName, "JSON output file, also the previous dataset")
+	csvFile := fs.String("csv", csvFileName, "CSV output file")
+	failuresFile := fs.String("failures", failuresFileName, "report of incidents that failed")
+	incremental := fs.Bool("incremental", false, "only fetch ids missing from the existing dataset plus a window of recent ones")
+	recheckWindow := fs.Int("recheck", 200, "number of most recent known ids re-fetched in -incremental mode")
+	removalAlert := fs.Int("removal-alert", 50, "fail without publishing when more ids than this disappear from the listing in one run")
+	csvLegacy := fs.Bool("csv-legacy", false, "write the CSV in the old unquoted format, without removed incidents")
+	excludeRemoved := fs.Bool("exclude-removed", false, "leave incidents no longer listed on the site out of the CSV, NDJSON and SQLite outputs; the JSON keeps them")
+	labelsMode := fs.String("unknown-labels", unknownLabelsWarn, "what to do when detail pages carry labels the parser doesn't know: warn, fail")
+	ndjsonFile := fs.String("ndjson", "", "also write the dataset as newline-delimited JSON to this file")
+	sqliteFile := fs.String("sqlite", "", "also write the dataset to this SQLite database, e.g. "+sqliteFileName)
+	validation := validationOptions{}
+	addValidationFlags(fs, &validation)
+	historyFile := fs.String("history", historyFileName, "append-only file recording every change to an incident, empty to disable")
+	resume := fs.Bool("resume", false, "continue the last interrupted crawl from its checkpoint")
+	checkpointInterval := fs.Duration("checkpoint-interval", 30*time.Second, "how often the checkpoint of a running crawl is saved")
+	fs.Parse(args)
+
+	if err := setup(); err != nil {
+		return err
+	}
+	if *labelsMode != unknownLabelsWarn && *labelsMode != unknownLabelsFail {
+		return fmt.Errorf("invalid -unknown-labels %q, expected %s or %s", *labelsMode, unknownLabelsWarn, unknownLabelsFail)
+	}
+	if *checkpointInterval <= 0 {
+		return fmt.Errorf("-checkpoint-interval must be positive, got %s", *checkpointInterval)
+	}
+
+	// The last good dataset backs incremental crawls and incidents that fail
+	previous, err := loadIncidents(*jsonFile)
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Cannot load existing dataset: %s", err)
+	}
+	existing := incidentsById(previous)
+
+	partialFile := partialFileName(*jsonFile)
+	checkpointFile := checkpointFileName(*jsonFile)
+
+	var state *checkpoint
+	if *resume {
+		state, err = loadCheckpoint(checkpointFile)
+		if os.IsNotExist(err) {
+			log.Printf("No checkpoint in %s, starting a new crawl", checkpointFile)
+		} else if err != nil {
+			return fmt.Errorf("cannot load checkpoint: %w", err)
+		}
+	} else if _, err := os.Stat(checkpointFile); err == nil {
+		log.Printf("Discarding the unfinished crawl in %s, use -resume to continue it", checkpointFile)
+	}
+
+	var entries, toFetch []listingEntry
+	var partial *ndjsonWriter
+	if state != nil {
+		// The partial file, not the checkpoint, tells which pages are done:
+		// it may have been written after the checkpoint was last saved
+		streamed, err := readNDJSON(partialFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		state.Done = nil
+		for _, incident := range streamed {
+			state.Done = append(state.Done, incident.Id)
+		}
+
+		// Rewrite the partial file without an incomplete last line before
+		// appending to it
+		if partial, err = createNDJSON(partialFile); err != nil {
+			return err
+		}
+		for _, incident := range streamed {
+			if err := partial.Write(incident); err != nil {
+				partial.Close()
+				return fmt.Errorf("failed to write %s: %w", partialFile, err)
+			}
+		}
+
+		entries = state.Entries
+		toFetch = state.remaining()
+		log.Printf("Resuming the crawl started at %s: %d of %d pages left", state.StartedAt.Format(time.RFC3339), len(toFetch), len(state.ToFetch))
+	} else {
+		// Url: https://anitsayac.com/?year=2000
+		entries = getListing(baseUrl+"/?year=2000", opts)
+		if len(entries) == 0 {
+			// An empty listing would turn every known incident into a tombstone
+			log.Println("No incidents found, not updating files")
+			return nil
+		}
+
+		toFetch = entries
+		if *incremental {
+			if previous == nil {
+				log.Println("No existing dataset, falling back to a full crawl")
+			} else {
+				toFetch = selectIncremental(entries, existing, *recheckWindow)
+				log.Printf("Incremental crawl: fetching %d of %d incidents", len(toFetch), len(entries))
+			}
+		}
+
+		// Stream every parsed page to disk so a late crash doesn't lose them
+		if partial, err = createNDJSON(partialFile); err != nil {
+			return err
+		}
+		state = &checkpoint{StartedAt: time.Now().UTC(), Entries: entries, ToFetch: ids(toFetch)}
+	}
+
+	progress := &checkpointer{path: checkpointFile, state: state}
+	if err := progress.Save(); err != nil {
+		partial.Close()
+		return fmt.Errorf("failed to write %s: %w", checkpointFile, err)
+	}
+
+	entriesById := make(map[int]listingEntry, len(entries))
+	for _, entry := range entries {
+		entriesById[entry.Id] = entry
+	}
+	opts.OnParsed = func(id int, page parsedPage) {
+		if err := partial.Write(newIncident(entriesById[id], page)); err != nil {
+			log.Printf("Failed to write %s: %s", partialFile, err)
+			return
+		}
+		progress.Done(id)
+	}
+
+	// SIGINT and SIGTERM stop new requests; the pages in flight still make
+	// it into the partial file before the checkpoint is saved
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	opts.Interrupt = ctx.Done()
+
+	stopSaving := progress.saveEvery(*checkpointInterval)
+	_, failures := getArticleContents(toFetch, opts)
+	stopSaving()
+	if err := partial.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", partialFile, err)
+	}
+	if err := progress.Save(); err != nil {
+		log.Printf("Failed to write %s: %s", checkpointFile, err)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted with %d of %d pages done, run again with -resume to continue", len(state.Done), len(state.ToFetch))
+	}
+	stop()
+
+	if err := writeFailures(*failuresFile, failures); err != nil {
+		log.Printf("Failed to write %s: %s", *failuresFile, err)
+	}
+	if len(failures) > 0 {
+		log.Printf("%d incident(s) failed, keeping their previous values (see %s)", len(failures), *failuresFile)
+	}
+
+	// The dataset is finalized from the streamed pages
+	streamed, err := readNDJSON(partialFile)
+	if err != nil {
+		return err
+	}
+	fetched := incidentsById(streamed)
+
+	incidents := make([]Incident, 0, len(entries))
+	listed := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		listed[entry.Id] = true
+		if incident, ok := fetched[entry.Id]; ok {
+			incidents = append(incidents, incident)
+			continue
+		}
+		if previous, ok := existing[entry.Id]; ok {
+			// Not re-fetched or failed this run, keep the last published values
+			previous.Name = entry.Name
+			previous.Url = entry.Url
+			previous.RemovedAt = ""
+			incidents = append(incidents, upgradeIncident(previous))
+			continue
+		}
+		// Failed without previous values, keep what the listing knows
+		incidents = append(incidents, newIncident(entry, parsedPage{}))
+	}
+
+	// Incidents that vanished from the listing are kept as tombstones
+	removed, newlyRemoved := tombstones(previous, listed, time.Now())
+	if newlyRemoved > *removalAlert {
+		return fmt.Errorf("%d incidents disappeared from the listing, more than -removal-alert %d; not updating files", newlyRemoved, *removalAlert)
+	}
+	if newlyRemoved > 0 {
+		log.Printf("%d incident(s) removed from the listing, %d tombstone(s) in total", newlyRemoved, len(removed))
+	}
+	incidents = append(incidents, removed...)
+
+	reportUnresolvedLocations(incidents)
+	reportUnmappedRelations(incidents)
+	if err := checkUnknownLabels(incidents, *labelsMode); err != nil {
+		return err
+	}
+
+	// Listed incidents come in listing order and tombstones after them, so
+	// sort both by id for stable output
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].Id < incidents[j].Id
+	})
+
+	writeCsv := writeCSV
+	if *csvLegacy {
+		writeCsv = writeLegacyCSV
+	}
+	// data.json keeps the tombstones, the next crawl needs them
+	exported := incidents
+	if *excludeRemoved {
+		exported = withoutRemoved(incidents)
+		csvWriter := writeCsv
+		writeCsv = func(w io.Writer, all []Incident) error {
+			return csvWriter(w, withoutRemoved(all))
+		}
+	}
+	validation.Previous = previous
+	if err := writeDataset(incidents, *jsonFile, *csvFile, writeCsv, validation); err != nil {
+		return err
+	}
+
+	os.Remove(partialFile)
+	os.Remove(checkpointFile)
+
+	if *ndjsonFile != "" {
+		if err := writeFileAtomic(*ndjsonFile, func(w io.Writer) error {
+			return writeNDJSON(w, exported)
+		}); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *ndjsonFile, err)
+		}
+	}
+	if *sqliteFile != "" {
+		if err := writeSQLite(*sqliteFile, exported); err != nil {
+			return fmt.Errorf("failed to write %s: %w", *sqliteFile, err)
+		}
+	}
+
+	// History follows the published dataset, so only record once it is
+	// written. Tombstones are left out so that a removal is recorded as such
+	// rather than as a change of removed_at
+	if *historyFile != "" {
+		if err := recordHistory(*historyFile, withoutRemoved(incidents), time.Now()); err != nil {
+			log.Printf("Failed to update %s: %s", *historyFile, err)
+		}
+	}
+
+	fmt.Printf("Successfully updated files with %d incidents\n", len(incidents))
+	return nil
+}
+
+// writeDataset writes incidents to temporary files first to avoid data
+// loss, and only replaces the originals once the new files and their
+// content validate.
+func writeDataset(incidents []Incident, jsonFile, csvFile string, writeCsv func(io.Writer, []Incident) error, validation validationOptions) error {
+	tempJsonFile := jsonFile + ".tmp"
+	tempCsvFile := csvFile + ".tmp"
+
+	if err := writeFile(tempJsonFile, func(w io.Writer) error {
+		return writeJSON(w, incidents)
+	}); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+
+	if err := writeFile(tempCsvFile, func(w io.Writer) error {
+		return writeCsv(w, incidents)
+	}); err != nil {
+		os.Remove(tempJsonFile)
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	// Validate temporary files before replacing originals
+	if !validateFiles(tempJsonFile, tempCsvFile) {
+		// Clean up temporary files
+		os.Remove(tempJsonFile)
+		os.Remove(tempCsvFile)
+		return errors.New("validation failed, not updating original files")
+	}
+
+	report, err := validation.validate(incidents)
+	if err == nil && !report.Passed {
+		err = fmt.Errorf("%d validation check(s) failed, not updating original files", len(report.Failed()))
+	}
+	if err != nil {
+		os.Remove(tempJsonFile)
+		os.Remove(tempCsvFile)
+		return err
+	}
+
+	// If validation passes, replace original files
+	if err := os.Rename(tempJsonFile, jsonFile); err != nil {
+		return fmt.Errorf("failed to replace JSON file: %w", err)
+	}
+	if err := os.Rename(tempCsvFile, csvFile); err != nil {
+		return fmt.Errorf("failed to replace CSV file: %w", err)
+	}
+	return nil
+}
+
+// writeFile creates path and fills it using write.
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// writeFileAtomic fills a temporary file using write and moves it to path
+// once complete.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tempPath := path + ".tmp"
+	if err := writeFile(tempPath, write); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// writeJSON encodes v as indented JSON, the format of data.json.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
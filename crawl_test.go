@@ -0,0 +1,346 @@
+package main
+
+import (
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"AnitSayac_Scrapper/crawler/internal/fakesite"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// goldenHost replaces the random address of the fake server in outputs.
+const goldenHost = "http://anitsayac.test"
+
+// TestCrawlEndToEnd runs the full crawl against a fake site built from the
+// recorded detail pages and compares every output file with its golden.
+func TestCrawlEndToEnd(t *testing.T) {
+	site, err := fakesite.LoadDir(filepath.Join("parser", "testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	site.Names[37902] = "Ayşenur H."
+	// Recovers on the third attempt
+	site.Flaky[37903] = 2
+	// Rate limited once, which must not be answered from the cache
+	site.RateLimited[35697] = 1
+	// Listed, but its detail page is gone
+	site.Listed = []int{40000}
+	// Links without an id, which are skipped
+	site.Links = []string{"#", "details.aspx", "details.aspx?id=", "details.aspx?id=abc"}
+
+	srv := httptest.NewServer(site)
+	defer srv.Close()
+
+	savedBaseUrl, savedCacheDir := baseUrl, cacheDir
+	t.Cleanup(func() { baseUrl, cacheDir = savedBaseUrl, savedCacheDir })
+
+	dir := t.TempDir()
+	outputs := map[string]string{
+		"data.json":       filepath.Join(dir, "data.json"),
+		"data.csv":        filepath.Join(dir, "data.csv"),
+		"failures.json":   filepath.Join(dir, "failures.json"),
+		"validation.json": filepath.Join(dir, "validation.json"),
+		"data.ndjson":     filepath.Join(dir, "data.ndjson"),
+	}
+
+	err = runCrawl([]string{
+		"-base-url", srv.URL,
+		"-cache-dir", filepath.Join(dir, "cache"),
+		"-json", outputs["data.json"],
+		"-csv", outputs["data.csv"],
+		"-failures", outputs["failures.json"],
+		"-report", outputs["validation.json"],
+		"-ndjson", outputs["data.ndjson"],
+		"-history", filepath.Join(dir, "history.ndjson"),
+		"-delay", "0",
+		"-random-delay", "0",
+		"-retry-delay", "1ms",
+		"-retry-max-delay", "1ms",
+	})
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	if err := runCrawl([]string{
+		"-base-url", srv.URL,
+		"-cache-dir", filepath.Join(dir, "cache"),
+		"-json", filepath.Join(dir, "unpublished.json"),
+		"-csv", filepath.Join(dir, "unpublished.csv"),
+		"-failures", filepath.Join(dir, "unpublished-failures.json"),
+		"-report", "",
+		"-history", "",
+		"-unknown-labels", "fail",
+	}); err == nil {
+		t.Error("crawl with -unknown-labels fail succeeded despite the labels of 50001")
+	}
+
+	if _, err := os.Stat(partialFileName(outputs["data.json"])); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind after a successful crawl: %v", err)
+	}
+
+	if got := site.Requests(37903); got != 3 {
+		t.Errorf("flaky page requested %d times, want 3", got)
+	}
+	if got := site.Requests(35697); got != 2 {
+		t.Errorf("rate limited page requested %d times, want 2", got)
+	}
+
+	for name, path := range outputs {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = []byte(strings.ReplaceAll(string(got), srv.URL, goldenHost))
+		compareGolden(t, filepath.Join("testdata", "e2e", name), got)
+	}
+}
+
+func compareGolden(t *testing.T, goldenFile string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(goldenFile, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("%s (run with -update to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("mismatch with %s\ngot:\n%s\nwant:\n%s", goldenFile, got, want)
+	}
+}
+
+// TestCrawlKeepsRemovedIncidents checks that an incident disappearing from
+// the listing is kept as a tombstone and recorded as removed in the
+// history, that -removal-alert stops the run and that a reappearing incident
+// is fetched again.
+func TestCrawlKeepsRemovedIncidents(t *testing.T) {
+	site, err := fakesite.LoadDir(filepath.Join("parser", "testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(site)
+	defer srv.Close()
+
+	savedBaseUrl, savedCacheDir := baseUrl, cacheDir
+	t.Cleanup(func() { baseUrl, cacheDir = savedBaseUrl, savedCacheDir })
+
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "data.json")
+	historyFile := filepath.Join(dir, "history.ndjson")
+	crawl := func(extra ...string) error {
+		return runCrawl(append([]string{
+			"-base-url", srv.URL,
+			// No cache, so the listing reflects the site on every run
+			"-cache-dir", "",
+			"-json", jsonFile,
+			"-csv", filepath.Join(dir, "data.csv"),
+			"-failures", filepath.Join(dir, "failures.json"),
+			"-history", historyFile,
+			"-report", "",
+			// Losing one of five incidents is over any sensible threshold,
+			// and so is the fill rate change of 151, which lacks some fields
+			"-max-count-drop", "1",
+			"-max-fill-drop", "1",
+			"-delay", "0",
+			"-random-delay", "0",
+		}, extra...))
+	}
+
+	if err := crawl(); err != nil {
+		t.Fatal(err)
+	}
+	listed := len(site.Pages)
+	page151 := site.Pages[151]
+	delete(site.Pages, 151)
+
+	if err := crawl("-removal-alert", "0"); err == nil {
+		t.Error("crawl with -removal-alert 0 succeeded after a removal")
+	}
+	if err := crawl(); err != nil {
+		t.Fatal(err)
+	}
+
+	incidents, err := loadIncidents(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byId := incidentsById(incidents)
+	if len(byId) != listed {
+		t.Errorf("got %d incidents, want %d", len(byId), listed)
+	}
+	if removed := byId[151]; removed.RemovedAt == "" || removed.FullName != "Beyhan Yavuz" {
+		t.Errorf("incident 151 = %+v, want a tombstone with its last known values", removed)
+	}
+	if kept := withoutRemoved(incidents); len(kept) != listed-1 {
+		t.Errorf("withoutRemoved kept %d incidents, want %d", len(kept), listed-1)
+	}
+	wantTimeline := []string{historyRevision, historyRemoved}
+	checkTimeline := func() {
+		t.Helper()
+		h, err := openHistory(historyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range h.Timeline(151) {
+			got = append(got, entry.Type)
+		}
+		if !reflect.DeepEqual(got, wantTimeline) {
+			t.Errorf("timeline of 151 = %v, want %v", got, wantTimeline)
+		}
+	}
+	checkTimeline()
+
+	// Only data.json keeps the tombstone with -exclude-removed, and the
+	// legacy CSV always leaves it out
+	for _, flags := range [][]string{{"-exclude-removed"}, {"-csv-legacy"}} {
+		if err := crawl(flags...); err != nil {
+			t.Fatal(err)
+		}
+		csv, err := os.ReadFile(filepath.Join(dir, "data.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(csv), "Beyhan Yavuz") {
+			t.Errorf("%v: data.csv lists the tombstone of 151", flags)
+		}
+		incidents, err := loadIncidents(jsonFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if incidentsById(incidents)[151].RemovedAt == "" {
+			t.Errorf("%v: data.json lost the tombstone of 151", flags)
+		}
+	}
+
+	// The tombstone is outside the -recheck window, yet fetched again once
+	// back on the listing, in both modes
+	for _, mode := range [][]string{{"-incremental", "-recheck", "0"}, nil} {
+		site.Pages[151] = page151
+		before := site.Requests(151)
+		if err := crawl(mode...); err != nil {
+			t.Fatal(err)
+		}
+		if got := site.Requests(151) - before; got != 1 {
+			t.Errorf("%v: reappearing incident requested %d times, want 1", mode, got)
+		}
+		incidents, err := loadIncidents(jsonFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back := incidentsById(incidents)[151]; back.RemovedAt != "" {
+			t.Errorf("%v: reappearing incident still removed at %s", mode, back.RemovedAt)
+		}
+
+		delete(site.Pages, 151)
+		if err := crawl(); err != nil {
+			t.Fatal(err)
+		}
+		wantTimeline = append(wantTimeline, historyRevision, historyRemoved)
+		checkTimeline()
+	}
+}
+
+// TestCrawlResume checks that -resume only fetches the pages missing from
+// the partial file of an interrupted crawl and then publishes all of them.
+func TestCrawlResume(t *testing.T) {
+	site, err := fakesite.LoadDir(filepath.Join("parser", "testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(site)
+	defer srv.Close()
+
+	savedBaseUrl, savedCacheDir := baseUrl, cacheDir
+	t.Cleanup(func() { baseUrl, cacheDir = savedBaseUrl, savedCacheDir })
+
+	dir := t.TempDir()
+	crawl := func(jsonFile string, extra ...string) error {
+		return runCrawl(append([]string{
+			"-base-url", srv.URL,
+			"-cache-dir", "",
+			"-json", jsonFile,
+			"-csv", filepath.Join(dir, "data.csv"),
+			"-failures", filepath.Join(dir, "failures.json"),
+			"-history", "",
+			"-report", "",
+			"-delay", "0",
+			"-random-delay", "0",
+		}, extra...))
+	}
+
+	complete := filepath.Join(dir, "complete.json")
+	if err := crawl(complete, "-checkpoint-interval", "0"); err == nil {
+		t.Error("crawl with -checkpoint-interval 0 succeeded")
+	}
+	if err := crawl(complete); err != nil {
+		t.Fatal(err)
+	}
+	incidents, err := loadIncidents(complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupted after two pages, with a third cut short mid-line
+	jsonFile := filepath.Join(dir, "data.json")
+	state := checkpoint{}
+	for _, incident := range incidents {
+		state.Entries = append(state.Entries, listingEntry{Id: incident.Id, Name: incident.Name, Url: incident.Url})
+		state.ToFetch = append(state.ToFetch, incident.Id)
+	}
+	done := incidents[:2]
+	partial, err := os.Create(partialFileName(jsonFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeNDJSON(partial, done); err != nil {
+		t.Fatal(err)
+	}
+	partial.WriteString(`{"id":`)
+	partial.Close()
+	progress := &checkpointer{path: checkpointFileName(jsonFile), state: &state}
+	if err := progress.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	before := map[int]int{}
+	for _, incident := range incidents {
+		before[incident.Id] = site.Requests(incident.Id)
+	}
+	if err := crawl(jsonFile, "-resume"); err != nil {
+		t.Fatal(err)
+	}
+	for i, incident := range incidents {
+		want := 1
+		if i < len(done) {
+			want = 0
+		}
+		if got := site.Requests(incident.Id) - before[incident.Id]; got != want {
+			t.Errorf("incident %d requested %d times on resume, want %d", incident.Id, got, want)
+		}
+	}
+
+	resumed, err := loadIncidents(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed) != len(incidents) {
+		t.Errorf("got %d incidents after resuming, want %d", len(resumed), len(incidents))
+	}
+	for _, path := range []string{partialFileName(jsonFile), checkpointFileName(jsonFile)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s left behind after a successful crawl: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// diffFormats are the formats a dataset diff can be printed in.
+var diffFormats = map[string]func(io.Writer, datasetDiff) error{
+	"text":     writeDiffText,
+	"json":     func(w io.Writer, d datasetDiff) error { return writeJSON(w, d) },
+	"markdown": writeDiffMarkdown,
+}
+
+// diffEntry identifies an added or removed incident.
+type diffEntry struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// fieldChange is a single field whose value differs between two datasets.
+// Field is the JSON name of the field.
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// incidentChange lists the changed fields of an incident found in both
+// datasets.
+type incidentChange struct {
+	Id      int           `json:"id"`
+	Name    string        `json:"name"`
+	Changes []fieldChange `json:"changes"`
+}
+
+// datasetDiff is the difference between two datasets keyed on incident id,
+// each list sorted by id.
+type datasetDiff struct {
+	Added    []diffEntry      `json:"added"`
+	Removed  []diffEntry      `json:"removed"`
+	Modified []incidentChange `json:"modified"`
+}
+
+// diffIncidents compares two datasets.
+func diffIncidents(old, new []Incident) datasetDiff {
+	d := datasetDiff{Added: []diffEntry{}, Removed: []diffEntry{}, Modified: []incidentChange{}}
+	oldById, newById := incidentsById(old), incidentsById(new)
+
+	for id, o := range oldById {
+		n, ok := newById[id]
+		if !ok {
+			d.Removed = append(d.Removed, diffEntry{Id: id, Name: o.Name})
+			continue
+		}
+		if changes := compareIncidents(o, n); len(changes) > 0 {
+			d.Modified = append(d.Modified, incidentChange{Id: id, Name: n.Name, Changes: changes})
+		}
+	}
+	for id, n := range newById {
+		if _, ok := oldById[id]; !ok {
+			d.Added = append(d.Added, diffEntry{Id: id, Name: n.Name})
+		}
+	}
+
+	sort.Slice(d.Added, func(i, j int) bool { return d.Added[i].Id < d.Added[j].Id })
+	sort.Slice(d.Removed, func(i, j int) bool { return d.Removed[i].Id < d.Removed[j].Id })
+	sort.Slice(d.Modified, func(i, j int) bool { return d.Modified[i].Id < d.Modified[j].Id })
+	return d
+}
+
+// compareIncidents returns the fields of two versions of an incident that
+// differ, in struct order.
+func compareIncidents(old, new Incident) []fieldChange {
+	var changes []fieldChange
+	newValues := incidentValues(new)
+	for i, o := range incidentValues(old) {
+		if n := newValues[i]; o.Value != n.Value {
+			changes = append(changes, fieldChange{Field: o.Field, Old: o.Value, New: n.Value})
+		}
+	}
+	return changes
+}
+
+// fieldValue is a field of an incident formatted for display.
+type fieldValue struct {
+	Field string
+	Value string
+}
+
+// incidentValues returns the data fields of an incident in struct order,
+// keyed by their JSON name. Warnings are diagnostics rather than data and
+// are left out, as is the schema version every incident shares.
+func incidentValues(incident Incident) []fieldValue {
+	var values []fieldValue
+	v := reflect.ValueOf(incident)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "warnings" || name == "schema_version" {
+			continue
+		}
+		values = append(values, fieldValue{Field: name, Value: formatValue(v.Field(i))})
+	}
+	return values
+}
+
+// formatValue renders a field value for display. Lists and maps are encoded
+// as JSON so that entries stay distinguishable.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int:
+		return optionalInt(int(v.Int()))
+	case reflect.Float64:
+		return optionalFloat(v.Float())
+	case reflect.Slice, reflect.Map:
+		if v.Len() == 0 {
+			return ""
+		}
+		encoded, _ := json.Marshal(v.Interface())
+		return string(encoded)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func (d datasetDiff) summary() string {
+	return fmt.Sprintf("%d added, %d removed, %d modified", len(d.Added), len(d.Removed), len(d.Modified))
+}
+
+// writeDiffText writes the diff for reading in a terminal.
+func writeDiffText(w io.Writer, d datasetDiff) error {
+	var b strings.Builder
+	fmt.Fprintln(&b, d.summary())
+
+	if len(d.Added) > 0 {
+		fmt.Fprintln(&b, "\nAdded:")
+		for _, e := range d.Added {
+			fmt.Fprintf(&b, "  + %d %s\n", e.Id, e.Name)
+		}
+	}
+	if len(d.Removed) > 0 {
+		fmt.Fprintln(&b, "\nRemoved:")
+		for _, e := range d.Removed {
+			fmt.Fprintf(&b, "  - %d %s\n", e.Id, e.Name)
+		}
+	}
+	if len(d.Modified) > 0 {
+		fmt.Fprintln(&b, "\nModified:")
+		for _, m := range d.Modified {
+			fmt.Fprintf(&b, "  ~ %d %s\n", m.Id, m.Name)
+			for _, c := range m.Changes {
+				fmt.Fprintf(&b, "      %s: %q → %q\n", c.Field, c.Old, c.New)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeDiffMarkdown writes the diff as a changelog that can be posted on
+// GitHub.
+func writeDiffMarkdown(w io.Writer, d datasetDiff) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Dataset changes\n\n%s\n", d.summary())
+
+	if len(d.Added) > 0 {
+		fmt.Fprint(&b, "\n### Added\n\n")
+		for _, e := range d.Added {
+			fmt.Fprintf(&b, "- %d %s\n", e.Id, markdownEscape(e.Name))
+		}
+	}
+	if len(d.Removed) > 0 {
+		fmt.Fprint(&b, "\n### Removed\n\n")
+		for _, e := range d.Removed {
+			fmt.Fprintf(&b, "- %d %s\n", e.Id, markdownEscape(e.Name))
+		}
+	}
+	if len(d.Modified) > 0 {
+		fmt.Fprint(&b, "\n### Modified\n\n")
+		fmt.Fprintln(&b, "| Id | Name | Field | Old | New |")
+		fmt.Fprintln(&b, "| --- | --- | --- | --- | --- |")
+		for _, m := range d.Modified {
+			for _, c := range m.Changes {
+				fmt.Fprintf(&b, "| %d | %s | %s | %s | %s |\n", m.Id, markdownEscape(m.Name), c.Field, markdownEscape(c.Old), markdownEscape(c.New))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var markdownReplacer = strings.NewReplacer(
+	"\\", "\\\\", "|", "\\|", "*", "\\*", "_", "\\_", "`", "\\`",
+	"<", "&lt;", ">", "&gt;", "\r", "", "\n", "<br>",
+)
+
+// markdownEscape makes s safe to use inline and within table cells.
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// runDiff compares two datasets.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: "+strings.Join(diffFormatNames(), ", "))
+	out := fs.String("out", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		return fmt.Errorf("expected two datasets, got %d argument(s)", fs.NArg())
+	}
+	write, ok := diffFormats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected one of: %s", *format, strings.Join(diffFormatNames(), ", "))
+	}
+
+	// Both sides are upgraded so that a dataset published before a derived
+	// field existed doesn't show it as changed on every incident
+	var datasets [2][]Incident
+	for i, path := range fs.Args() {
+		incidents, err := loadIncidents(path)
+		if err != nil {
+			return err
+		}
+		for j := range incidents {
+			incidents[j] = upgradeIncident(incidents[j])
+		}
+		datasets[i] = incidents
+	}
+
+	d := diffIncidents(datasets[0], datasets[1])
+	if *out == "" {
+		return write(os.Stdout, d)
+	}
+	return writeFile(*out, func(w io.Writer) error {
+		return write(w, d)
+	})
+}
+
+func diffFormatNames() []string {
+	names := make([]string, 0, len(diffFormats))
+	for name := range diffFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
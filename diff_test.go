@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"AnitSayac_Scrapper/crawler/parser"
+)
+
+func TestDiffIncidents(t *testing.T) {
+	old := []Incident{
+		{Id: 1, Name: "Removed"},
+		{Id: 2, Name: "Same", Status: "Tutuklu"},
+		{Id: 3, Name: "Changed", Status: "Soruşturma Sürüyor", Source: []string{"a"}},
+	}
+	new := []Incident{
+		{Id: 4, Name: "Added"},
+		{Id: 3, Name: "Changed", Status: "Tutuklu", Source: []string{"a", "b"}},
+		{Id: 2, Name: "Same", Status: "Tutuklu", Warnings: []parser.Warning{{Field: "date", Message: "empty date"}}},
+	}
+
+	want := datasetDiff{
+		Added:   []diffEntry{{Id: 4, Name: "Added"}},
+		Removed: []diffEntry{{Id: 1, Name: "Removed"}},
+		Modified: []incidentChange{{Id: 3, Name: "Changed", Changes: []fieldChange{
+			{Field: "status", Old: "Soruşturma Sürüyor", New: "Tutuklu"},
+			{Field: "source", Old: `["a"]`, New: `["a","b"]`},
+		}}},
+	}
+	if got := diffIncidents(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffIncidents() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteDiffMarkdownEscapes(t *testing.T) {
+	d := datasetDiff{Modified: []incidentChange{{Id: 1, Name: "A|B", Changes: []fieldChange{
+		{Field: "status", Old: "line\nbreak", New: "<b>"},
+	}}}}
+
+	var b strings.Builder
+	if err := writeDiffMarkdown(&b, d); err != nil {
+		t.Fatal(err)
+	}
+	if row := `| 1 | A\|B | status | line<br>break | &lt;b&gt; |`; !strings.Contains(b.String(), row) {
+		t.Errorf("markdown output misses %q:\n%s", row, b.String())
+	}
+}
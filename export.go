@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// exportFormats are the formats data.json can be converted to.
+var exportFormats = map[string]func(io.Writer, []Incident) error{
+	"json":       func(w io.Writer, incidents []Incident) error { return writeJSON(w, incidents) },
+	"csv":        writeCSV,
+	"csv-legacy": writeLegacyCSV,
+	"ndjson":     writeNDJSON,
+	"parquet":    writeParquet,
+}
+
+var (
+	csvHeader       = []string{"Id", "Name", "FullName", "Age", "Location", "Province", "District", "ProvinceCode", "LocationConfidence", "Date", "DateISO", "Year", "Month", "Reason", "By", "ByRelation", "ByCategory", "Protection", "Method", "Status", "Source", "Image", "Url", "RemovedAt", "Extra", "SchemaVersion"}
+	legacyCsvHeader = []string{"Id", "Name", "FullName", "Age", "Location", "Date", "Reason", "By", "Protection", "Method", "Status", "Source", "Image", "Url"}
+)
+
+// writeCSV writes incidents as RFC 4180 CSV. Fields are quoted as needed
+// so every value round-trips exactly; Source is encoded as a JSON array
+// because source entries may themselves contain spaces and line breaks, and
+// Extra as a JSON object.
+// Unknown numeric values are left empty.
+func writeCSV(w io.Writer, incidents []Incident) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, incident := range incidents {
+		source := ""
+		if incident.Source != nil {
+			encoded, err := json.Marshal(incident.Source)
+			if err != nil {
+				return err
+			}
+			source = string(encoded)
+		}
+		extra := ""
+		if len(incident.Extra) > 0 {
+			encoded, err := json.Marshal(incident.Extra)
+			if err != nil {
+				return err
+			}
+			extra = string(encoded)
+		}
+
+		if err := cw.Write([]string{
+			strconv.Itoa(incident.Id),
+			incident.Name,
+			incident.FullName,
+			incident.Age,
+			incident.Location,
+			incident.Province,
+			incident.District,
+			optionalInt(incident.ProvinceCode),
+			optionalFloat(incident.LocationConfidence),
+			incident.Date,
+			incident.DateISO,
+			optionalInt(incident.Year),
+			optionalInt(incident.Month),
+			incident.Reason,
+			incident.By,
+			incident.ByRelation,
+			incident.ByCategory,
+			incident.Protection,
+			incident.Method,
+			incident.Status,
+			source,
+			incident.Image,
+			incident.Url,
+			incident.RemovedAt,
+			extra,
+			optionalInt(incident.SchemaVersion),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeLegacyCSV writes the original unquoted format kept for existing
+// consumers: commas are stripped from categorical fields and encoded as
+// %2C in sources. The format has no column telling tombstones apart, so
+// they are left out.
+func writeLegacyCSV(w io.Writer, incidents []Incident) error {
+	if _, err := io.WriteString(w, strings.Join(legacyCsvHeader, ",")+"\n"); err != nil {
+		return err
+	}
+
+	for _, incident := range withoutRemoved(incidents) {
+		if _, err := fmt.Fprintf(w, "%d,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
+			incident.Id,
+			incident.Name,
+			incident.FullName,
+			incident.Age,
+			incident.Location,
+			incident.Date,
+			strings.Join(strings.Split(incident.Reason, ","), " "),
+			strings.Join(strings.Split(incident.By, ","), " "),
+			strings.Join(strings.Split(incident.Protection, ","), " "),
+			strings.Join(strings.Split(incident.Method, ","), " "),
+			strings.Join(strings.Split(incident.Status, ","), " "),
+			ReplaceAll(ReplaceAll(strings.Join(incident.Source, " "), ",", "%2C", 1), "\n", "", 1),
+			incident.Image,
+			incident.Url,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// optionalInt formats n, leaving zero values empty.
+func optionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// optionalFloat formats f, leaving zero values empty.
+func optionalFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// runExport converts an existing data.json to another format.
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	in := fs.String("in", jsonFileName, "JSON dataset to convert")
+	out := fs.String("out", "", "output file (default stdout)")
+	format := fs.String("format", "csv", "output format: "+strings.Join(formatNames(), ", "))
+	excludeRemoved := fs.Bool("exclude-removed", false, "leave out incidents no longer listed on the site")
+	fs.Parse(args)
+
+	write, ok := exportFormats[*format]
+	if !ok && *format != "sqlite" {
+		return fmt.Errorf("unknown format %q, expected one of: %s", *format, strings.Join(formatNames(), ", "))
+	}
+	if *format == "sqlite" && *out == "" {
+		return errors.New("-format sqlite needs an -out file")
+	}
+
+	incidents, err := loadIncidents(*in)
+	if err != nil {
+		return err
+	}
+	for i := range incidents {
+		incidents[i] = upgradeIncident(incidents[i])
+	}
+	if *excludeRemoved {
+		incidents = withoutRemoved(incidents)
+	}
+
+	if *format == "sqlite" {
+		// A database is built on disk rather than streamed
+		return writeSQLite(*out, incidents)
+	}
+	if *out == "" {
+		return write(os.Stdout, incidents)
+	}
+	return writeFile(*out, func(w io.Writer) error {
+		return write(w, incidents)
+	})
+}
+
+func formatNames() []string {
+	names := []string{"sqlite"}
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	incidents := []Incident{
+		{
+			Id:     1,
+			Name:   `Ayşe "Ayşo" Y.`,
+			Reason: "Kıskançlık, Tartışma",
+			By:     "Abisi, Babası",
+			Source: []string{"https://example.com/a?x=1,2", "Gazete haberi,\nikinci satır"},
+		},
+		{Id: 2, Name: "B\nC", Reason: "Reddetme"},
+	}
+
+	var b strings.Builder
+	if err := writeCSV(&b, incidents); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(incidents)+1 || !reflect.DeepEqual(records[0], csvHeader) {
+		t.Fatalf("got %d records with header %v", len(records), records[0])
+	}
+
+	column := map[string]int{}
+	for i, name := range csvHeader {
+		column[name] = i
+	}
+	for i, incident := range incidents {
+		record := records[i+1]
+		for name, want := range map[string]string{"Name": incident.Name, "Reason": incident.Reason, "By": incident.By} {
+			if got := record[column[name]]; got != want {
+				t.Errorf("incident %d: %s = %q, want %q", incident.Id, name, got, want)
+			}
+		}
+
+		var source []string
+		if record[column["Source"]] != "" {
+			if err := json.Unmarshal([]byte(record[column["Source"]]), &source); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !reflect.DeepEqual(source, incident.Source) {
+			t.Errorf("incident %d: source = %q, want %q", incident.Id, source, incident.Source)
+		}
+	}
+}
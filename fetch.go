@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// runFetch fetches a single incident and prints it as JSON.
+func runFetch(args []string) error {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	opts := crawlOptions{}
+	setup := addCrawlFlags(fs, &opts)
+	fs.Parse(args)
+
+	if err := setup(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected a single incident id")
+	}
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid incident id %q", fs.Arg(0))
+	}
+
+	entry := listingEntry{Id: id, Url: fmt.Sprintf("%s/details.aspx?id=%d", baseUrl, id)}
+	details, failures := getArticleContents([]listingEntry{entry}, opts)
+	if len(failures) > 0 {
+		return fmt.Errorf("%s: %s", failures[0].Url, failures[0].Error)
+	}
+
+	// Only the listing page carries the short name, use the full one
+	page := details[id]
+	entry.Name = page.Detail.Name
+	return writeJSON(os.Stdout, newIncident(entry, page))
+}
@@ -0,0 +1,216 @@
+// Package gazetteer resolves free-text locations into Turkey's provinces and
+// districts.
+//
+// The embedded gazetteer.json lists the 81 provinces with their plate codes
+// and districts, plus the Turkish Republic of Northern Cyprus under
+// "Kıbrıs" with plate code 0.
+package gazetteer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+//go:embed gazetteer.json
+var gazetteerJSON []byte
+
+// Match confidence levels, from the most to the least certain.
+const (
+	// ConfidenceExact is a province, or a "District/Province" pair, written
+	// exactly as in the gazetteer.
+	ConfidenceExact = 1.0
+	// ConfidenceNormalized matched only after ignoring case and Turkish
+	// diacritics, e.g. "Istanbul".
+	ConfidenceNormalized = 0.9
+	// ConfidenceAlias matched a historical or colloquial province name,
+	// e.g. "Urfa" or "Dersim".
+	ConfidenceAlias = 0.8
+	// ConfidenceDistrict is a bare district name that exists in a single
+	// province, e.g. "Bodrum".
+	ConfidenceDistrict = 0.6
+)
+
+// Province is a gazetteer entry.
+type Province struct {
+	Code      int      `json:"code"`
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases,omitempty"`
+	Districts []string `json:"districts"`
+}
+
+// Match is the structured form of a resolved location. The zero value means
+// the location could not be resolved.
+type Match struct {
+	Province     string
+	District     string
+	ProvinceCode int
+	Confidence   float64
+}
+
+// Resolved reports whether the location was matched to a province.
+func (m Match) Resolved() bool {
+	return m.Province != ""
+}
+
+// name is a gazetteer name along with how it was written.
+type name struct {
+	canonical string
+	exact     string
+	alias     bool
+}
+
+// Gazetteer resolves locations against a list of provinces.
+type Gazetteer struct {
+	provinces map[string]*Province
+	// byKey maps a normalized province name or alias to its province.
+	byKey map[string]name
+	// districts maps a normalized district name to the provinces having it.
+	districts map[string][]districtRef
+}
+
+type districtRef struct {
+	province *Province
+	district string
+}
+
+// Default returns the gazetteer embedded in the binary.
+func Default() *Gazetteer {
+	var data struct {
+		Version   int        `json:"version"`
+		Provinces []Province `json:"provinces"`
+	}
+	if err := json.Unmarshal(gazetteerJSON, &data); err != nil {
+		panic("gazetteer: invalid embedded data: " + err.Error())
+	}
+	return New(data.Provinces)
+}
+
+// New returns a gazetteer over the given provinces.
+func New(provinces []Province) *Gazetteer {
+	g := &Gazetteer{
+		provinces: map[string]*Province{},
+		byKey:     map[string]name{},
+		districts: map[string][]districtRef{},
+	}
+
+	for i := range provinces {
+		p := &provinces[i]
+		g.provinces[p.Name] = p
+		g.byKey[Normalize(p.Name)] = name{canonical: p.Name, exact: p.Name}
+		for _, alias := range p.Aliases {
+			g.byKey[Normalize(alias)] = name{canonical: p.Name, exact: alias, alias: true}
+		}
+		for _, d := range p.Districts {
+			key := Normalize(d)
+			g.districts[key] = append(g.districts[key], districtRef{province: p, district: d})
+		}
+	}
+	return g
+}
+
+// Provinces returns every province ordered by plate code.
+func (g *Gazetteer) Provinces() []Province {
+	provinces := make([]Province, 0, len(g.provinces))
+	for _, p := range g.provinces {
+		provinces = append(provinces, *p)
+	}
+	sort.Slice(provinces, func(i, j int) bool {
+		return provinces[i].Code < provinces[j].Code
+	})
+	return provinces
+}
+
+// Resolve matches a location such as "İzmir", "Buca/İzmir" or "Bodrum".
+// Values that are empty, ambiguous or unknown return the zero Match.
+func (g *Gazetteer) Resolve(location string) Match {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return Match{}
+	}
+
+	if before, after, found := strings.Cut(location, "/"); found {
+		return g.resolvePair(strings.TrimSpace(before), strings.TrimSpace(after))
+	}
+
+	if p, confidence, ok := g.province(location); ok {
+		return Match{Province: p.Name, ProvinceCode: p.Code, Confidence: confidence}
+	}
+
+	// A bare district is only accepted when no other province shares it
+	refs := g.districts[Normalize(location)]
+	if len(refs) == 1 && Normalize(refs[0].district) != "merkez" {
+		return Match{
+			Province:     refs[0].province.Name,
+			District:     refs[0].district,
+			ProvinceCode: refs[0].province.Code,
+			Confidence:   ConfidenceDistrict,
+		}
+	}
+	return Match{}
+}
+
+// resolvePair matches a "District/Province" value.
+func (g *Gazetteer) resolvePair(district, province string) Match {
+	p, confidence, ok := g.province(province)
+	if !ok {
+		return Match{}
+	}
+	m := Match{Province: p.Name, ProvinceCode: p.Code, Confidence: confidence}
+
+	// "Dersim/Tunceli" names the province twice
+	if q, _, ok := g.province(district); ok && q == p {
+		return m
+	}
+
+	for _, ref := range g.districts[Normalize(district)] {
+		if ref.province == p {
+			m.District = ref.district
+			if ref.district != district {
+				m.Confidence = min(m.Confidence, ConfidenceNormalized)
+			}
+			return m
+		}
+	}
+
+	// Unknown district within a known province, keep the province only
+	m.Confidence = min(m.Confidence, ConfidenceDistrict)
+	return m
+}
+
+// province looks up a province by name or alias.
+func (g *Gazetteer) province(s string) (*Province, float64, bool) {
+	n, ok := g.byKey[Normalize(s)]
+	if !ok {
+		return nil, 0, false
+	}
+
+	confidence := ConfidenceExact
+	switch {
+	case n.alias:
+		confidence = ConfidenceAlias
+	case n.exact != s:
+		confidence = ConfidenceNormalized
+	}
+	return g.provinces[n.canonical], confidence, true
+}
+
+var foldReplacer = strings.NewReplacer(
+	"İ", "i", "I", "i", "ı", "i",
+	"Ş", "s", "ş", "s",
+	"Ğ", "g", "ğ", "g",
+	"Ü", "u", "ü", "u",
+	"Ö", "o", "ö", "o",
+	"Ç", "c", "ç", "c",
+	"Â", "a", "â", "a",
+	"Î", "i", "î", "i",
+	"Û", "u", "û", "u",
+	".", "", " ", "", "-", "",
+)
+
+// Normalize folds case, Turkish diacritics, spaces and punctuation so that
+// "Istanbul", "istanbul" and "İstanbul" compare equal.
+func Normalize(s string) string {
+	return strings.ToLower(foldReplacer.Replace(strings.TrimSpace(s)))
+}
@@ -0,0 +1,50 @@
+package gazetteer
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	g := Default()
+
+	tests := []struct {
+		location string
+		want     Match
+	}{
+		{"İzmir", Match{Province: "İzmir", ProvinceCode: 35, Confidence: ConfidenceExact}},
+		{"Istanbul", Match{Province: "İstanbul", ProvinceCode: 34, Confidence: ConfidenceNormalized}},
+		{"Buca/İzmir", Match{Province: "İzmir", District: "Buca", ProvinceCode: 35, Confidence: ConfidenceExact}},
+		{"Tuzla/Istanbul", Match{Province: "İstanbul", District: "Tuzla", ProvinceCode: 34, Confidence: ConfidenceNormalized}},
+		{"Urfa", Match{Province: "Şanlıurfa", ProvinceCode: 63, Confidence: ConfidenceAlias}},
+		{"Dersim/Tunceli", Match{Province: "Tunceli", ProvinceCode: 62, Confidence: ConfidenceExact}},
+		{"Bodrum", Match{Province: "Muğla", District: "Bodrum", ProvinceCode: 48, Confidence: ConfidenceDistrict}},
+		{"Orhaniye/Muğla", Match{Province: "Muğla", ProvinceCode: 48, Confidence: ConfidenceDistrict}},
+		{"Girne/Kıbrıs", Match{Province: "Kıbrıs", District: "Girne", ProvinceCode: 0, Confidence: ConfidenceExact}},
+		{"Ereğli", Match{}},
+		{"Merkez", Match{}},
+		{"Ukrayna", Match{}},
+		{"", Match{}},
+	}
+
+	for _, tt := range tests {
+		if got := g.Resolve(tt.location); got != tt.want {
+			t.Errorf("Resolve(%q) = %+v, want %+v", tt.location, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultProvinces(t *testing.T) {
+	g := Default()
+
+	codes := map[int]bool{}
+	for _, p := range g.Provinces() {
+		if p.Code == 0 {
+			continue
+		}
+		if codes[p.Code] {
+			t.Errorf("duplicate plate code %d", p.Code)
+		}
+		codes[p.Code] = true
+	}
+	if len(codes) != 81 {
+		t.Errorf("got %d provinces with plate codes, want 81", len(codes))
+	}
+}
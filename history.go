@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Kinds of history records.
+const (
+	historyRun      = "run"
+	historyRevision = "revision"
+	historyRemoved  = "removed"
+)
+
+// historyRecord is a line of the history file. Every crawl appends a
+// revision record for each incident that is new or whose fields changed, a
+// removed record for each incident that is no longer listed on the site,
+// and finally a run record. The file is only ever appended to.
+type historyRecord struct {
+	Type string    `json:"type"`
+	At   time.Time `json:"at"`
+	Id   int       `json:"id,omitempty"`
+	// Changes of a revision; for a new incident, every non-empty field.
+	Changes []fieldChange `json:"changes,omitempty"`
+}
+
+// historyStore is the replayed state of a history file.
+type historyStore struct {
+	path    string
+	records []historyRecord
+	// state holds the last recorded values of every incident present in
+	// the dataset, keyed by field name.
+	state map[int]map[string]string
+}
+
+// openHistory replays the history file at path. A missing file is an empty
+// history.
+func openHistory(path string) (*historyStore, error) {
+	h := &historyStore{path: path, state: map[int]map[string]string{}}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		h.apply(record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return h, nil
+}
+
+func (h *historyStore) apply(record historyRecord) {
+	h.records = append(h.records, record)
+	switch record.Type {
+	case historyRevision:
+		values := h.state[record.Id]
+		if values == nil {
+			values = map[string]string{}
+			h.state[record.Id] = values
+		}
+		for _, c := range record.Changes {
+			values[c.Field] = c.New
+		}
+	case historyRemoved:
+		delete(h.state, record.Id)
+	}
+}
+
+// Record appends the changes between the recorded state and incidents, as
+// observed at the given time.
+func (h *historyStore) Record(incidents []Incident, at time.Time) error {
+	at = at.UTC()
+	var records []historyRecord
+
+	present := make(map[int]bool, len(incidents))
+	for _, incident := range incidents {
+		present[incident.Id] = true
+
+		var changes []fieldChange
+		recorded := h.state[incident.Id]
+		for _, v := range incidentValues(incident) {
+			old := recorded[v.Field]
+			if v.Value != old {
+				changes = append(changes, fieldChange{Field: v.Field, Old: old, New: v.Value})
+			}
+		}
+		if len(changes) > 0 {
+			records = append(records, historyRecord{Type: historyRevision, At: at, Id: incident.Id, Changes: changes})
+		}
+	}
+
+	var removed []int
+	for id := range h.state {
+		if !present[id] {
+			removed = append(removed, id)
+		}
+	}
+	sort.Ints(removed)
+	for _, id := range removed {
+		records = append(records, historyRecord{Type: historyRemoved, At: at, Id: id})
+	}
+	records = append(records, historyRecord{Type: historyRun, At: at})
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+		h.apply(record)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// recordHistory appends the changes in incidents to the history file.
+func recordHistory(path string, incidents []Incident, at time.Time) error {
+	h, err := openHistory(path)
+	if err != nil {
+		return err
+	}
+	return h.Record(incidents, at)
+}
+
+// historyEntry is a period of an incident's timeline: a revision, with the
+// first and last run that observed it, or its removal.
+type historyEntry struct {
+	Type      string        `json:"type"`
+	FirstSeen time.Time     `json:"first_seen"`
+	LastSeen  time.Time     `json:"last_seen"`
+	Changes   []fieldChange `json:"changes,omitempty"`
+}
+
+// Timeline returns the revisions and removals of an incident, oldest first.
+func (h *historyStore) Timeline(id int) []historyEntry {
+	var timeline []historyEntry
+	var current *historyEntry
+	for _, record := range h.records {
+		switch {
+		case record.Type == historyRun && current != nil:
+			// The run observed the revision in effect after its changes
+			current.LastSeen = record.At
+		case record.Id != id:
+		case record.Type == historyRevision:
+			timeline = append(timeline, historyEntry{Type: historyRevision, FirstSeen: record.At, LastSeen: record.At, Changes: record.Changes})
+			current = &timeline[len(timeline)-1]
+		case record.Type == historyRemoved:
+			timeline = append(timeline, historyEntry{Type: historyRemoved, FirstSeen: record.At, LastSeen: record.At})
+			current = nil
+		}
+	}
+	return timeline
+}
+
+func writeTimelineText(w io.Writer, id int, timeline []historyEntry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Incident %d\n", id)
+	for i, entry := range timeline {
+		period := entry.FirstSeen.Format(time.RFC3339)
+		if !entry.LastSeen.Equal(entry.FirstSeen) {
+			period += " – " + entry.LastSeen.Format(time.RFC3339)
+		}
+
+		switch {
+		case entry.Type == historyRemoved:
+			fmt.Fprintf(bw, "\n%s  removed\n", period)
+		case i == 0 || timeline[i-1].Type == historyRemoved:
+			fmt.Fprintf(bw, "\n%s  first seen\n", period)
+			for _, c := range entry.Changes {
+				fmt.Fprintf(bw, "    %s: %q\n", c.Field, c.New)
+			}
+		default:
+			fmt.Fprintf(bw, "\n%s  changed\n", period)
+			for _, c := range entry.Changes {
+				fmt.Fprintf(bw, "    %s: %q → %q\n", c.Field, c.Old, c.New)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// runHistory prints the recorded timeline of an incident.
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	historyFile := fs.String("history", historyFileName, "history file written by crawl")
+	format := fs.String("format", "text", "output format: text, json")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("expected a single incident id")
+	}
+	id, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid id %q", fs.Arg(0))
+	}
+
+	h, err := openHistory(*historyFile)
+	if err != nil {
+		return err
+	}
+	timeline := h.Timeline(id)
+	if len(timeline) == 0 {
+		return fmt.Errorf("no history for incident %d in %s", id, *historyFile)
+	}
+
+	switch *format {
+	case "text":
+		return writeTimelineText(os.Stdout, id, timeline)
+	case "json":
+		return writeJSON(os.Stdout, timeline)
+	}
+	return fmt.Errorf("unknown format %q, expected one of: text, json", *format)
+}
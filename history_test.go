@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistoryTimeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.ndjson")
+	day := func(d int) time.Time { return time.Date(2024, 10, d, 0, 0, 0, 0, time.UTC) }
+
+	runs := []struct {
+		at        time.Time
+		incidents []Incident
+	}{
+		{day(1), []Incident{{Id: 1, Name: "A", Status: "Soruşturma Sürüyor"}, {Id: 2, Name: "B"}}},
+		{day(2), []Incident{{Id: 1, Name: "A", Status: "Soruşturma Sürüyor"}}},
+		{day(3), []Incident{{Id: 1, Name: "A", Status: "Tutuklu"}}},
+		{day(4), []Incident{{Id: 1, Name: "A", Status: "Tutuklu"}, {Id: 2, Name: "B"}}},
+	}
+	for _, run := range runs {
+		// Reopen every time, as separate crawls do
+		if err := recordHistory(path, run.incidents, run.at); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := openHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []historyEntry{
+		{Type: historyRevision, FirstSeen: day(1), LastSeen: day(2), Changes: []fieldChange{
+			{Field: "id", New: "1"}, {Field: "name", New: "A"}, {Field: "status", New: "Soruşturma Sürüyor"},
+		}},
+		{Type: historyRevision, FirstSeen: day(3), LastSeen: day(4), Changes: []fieldChange{
+			{Field: "status", Old: "Soruşturma Sürüyor", New: "Tutuklu"},
+		}},
+	}
+	if got := h.Timeline(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Timeline(1) = %+v, want %+v", got, want)
+	}
+
+	want = []historyEntry{
+		{Type: historyRevision, FirstSeen: day(1), LastSeen: day(1), Changes: []fieldChange{
+			{Field: "id", New: "2"}, {Field: "name", New: "B"},
+		}},
+		{Type: historyRemoved, FirstSeen: day(2), LastSeen: day(2)},
+		{Type: historyRevision, FirstSeen: day(4), LastSeen: day(4), Changes: []fieldChange{
+			{Field: "id", New: "2"}, {Field: "name", New: "B"},
+		}},
+	}
+	if got := h.Timeline(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Timeline(2) = %+v, want %+v", got, want)
+	}
+}
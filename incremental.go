@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"AnitSayac_Scrapper/crawler/parser"
+)
+
+// loadIncidents reads a previously published dataset.
+func loadIncidents(path string) ([]Incident, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []Incident
+	if err := json.Unmarshal(content, &incidents); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	return incidents, nil
+}
+
+// incidentsById indexes incidents by their id.
+func incidentsById(incidents []Incident) map[int]Incident {
+	byId := make(map[int]Incident, len(incidents))
+	for _, incident := range incidents {
+		byId[incident.Id] = incident
+	}
+	return byId
+}
+
+// selectIncremental returns the listing entries that need to be fetched in
+// incremental mode: every id missing from the existing dataset plus the
+// recheckWindow highest ids that are already known, so recent incidents
+// whose details are still being filled in get re-verified. Tombstones that
+// reappear on the listing count as missing.
+func selectIncremental(entries []listingEntry, existing map[int]Incident, recheckWindow int) []listingEntry {
+	var fresh, known []listingEntry
+	for _, entry := range entries {
+		if previous, ok := existing[entry.Id]; ok && previous.RemovedAt == "" {
+			known = append(known, entry)
+		} else {
+			fresh = append(fresh, entry)
+		}
+	}
+
+	sort.Slice(known, func(i, j int) bool {
+		return known[i].Id > known[j].Id
+	})
+	if recheckWindow < len(known) {
+		known = known[:max(recheckWindow, 0)]
+	}
+
+	return append(fresh, known...)
+}
+
+// upgradeIncident recomputes the fields derived from raw values, which are
+// missing from datasets published before they were introduced.
+func upgradeIncident(incident Incident) Incident {
+	incident.SchemaVersion = schemaVersion
+	incident.Warnings = withoutField(incident.Warnings, "date")
+	date, problem := parser.ParseDate(incident.Date)
+	incident.DateISO, incident.Year, incident.Month = date.ISO, date.Year, date.Month
+	if problem != "" {
+		incident.Warnings = append(incident.Warnings, parser.Warning{Field: "date", Message: problem})
+	}
+
+	return classifyRelation(resolveLocation(incident))
+}
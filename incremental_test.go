@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectIncremental(t *testing.T) {
+	var entries []listingEntry
+	for _, id := range []int{7, 6, 5, 4, 3, 2, 1} {
+		entries = append(entries, listingEntry{Id: id})
+	}
+	existing := map[int]Incident{}
+	for _, id := range []int{1, 2, 3, 4} {
+		existing[id] = Incident{Id: id}
+	}
+	withTombstone := map[int]Incident{6: {Id: 6, RemovedAt: "2024-10-18T00:00:00Z"}}
+	for id, incident := range existing {
+		withTombstone[id] = incident
+	}
+
+	tests := []struct {
+		name     string
+		existing map[int]Incident
+		window   int
+		want     []int
+	}{
+		{"no known ids", map[int]Incident{}, 2, []int{7, 6, 5, 4, 3, 2, 1}},
+		{"window within known", existing, 2, []int{7, 6, 5, 4, 3}},
+		{"window larger than known", existing, 10, []int{7, 6, 5, 4, 3, 2, 1}},
+		{"window 0", existing, 0, []int{7, 6, 5}},
+		{"negative window", existing, -1, []int{7, 6, 5}},
+		{"reappearing tombstone", withTombstone, 1, []int{7, 6, 5, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []int{}
+			for _, entry := range selectIncremental(entries, tt.existing, tt.window) {
+				got = append(got, entry.Id)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package fakesite serves a stand-in for anitsayac.com built from recorded
+// detail pages, so the crawler can be exercised without network access.
+package fakesite
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var nameRe = regexp.MustCompile(`(?i)<b>Ad Soyad:\s*</b>\s*(.+?)<br>`)
+
+// Site is an in-memory copy of the site.
+type Site struct {
+	// Pages holds the body of each details.aspx?id= page.
+	Pages map[int][]byte
+	// Names holds the short names shown on the listing page. Pages without
+	// one are listed under the name found on the page itself.
+	Names map[int]string
+	// Listed holds ids shown on the listing page without a detail page,
+	// which then answers 404.
+	Listed []int
+	// Links holds extra hrefs shown on the listing page as they are, such
+	// as anchors without an id.
+	Links []string
+	// Flaky makes the detail page of an id answer 503 that many times
+	// before succeeding.
+	Flaky map[int]int
+	// RateLimited makes the detail page of an id answer 429 that many
+	// times after the Flaky ones before succeeding.
+	RateLimited map[int]int
+
+	mu       sync.Mutex
+	requests map[int]int
+}
+
+// LoadDir builds a site from the <id>.html files in dir.
+func LoadDir(dir string) (*Site, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	site := &Site{Pages: map[int][]byte{}, Names: map[int]string{}, Flaky: map[int]int{}, RateLimited: map[int]int{}}
+	for _, file := range files {
+		id, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), ".html"))
+		if err != nil {
+			continue
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		site.Pages[id] = body
+	}
+	return site, nil
+}
+
+// ids returns every id on the listing page, newest first like the site.
+func (s *Site) ids() []int {
+	seen := map[int]bool{}
+	var ids []int
+	for id := range s.Pages {
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	for _, id := range s.Listed {
+		if !seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+	return ids
+}
+
+func (s *Site) name(id int) string {
+	if name, ok := s.Names[id]; ok {
+		return name
+	}
+	if m := nameRe.FindSubmatch(s.Pages[id]); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	return fmt.Sprintf("Incident %d", id)
+}
+
+// Requests returns how many times the detail page of id was requested.
+func (s *Site) Requests(id int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[id]
+}
+
+// ServeHTTP serves the listing page on / and detail pages on /details.aspx.
+func (s *Site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		s.serveListing(w)
+	case "/details.aspx":
+		s.serveDetail(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Site) serveListing(w http.ResponseWriter) {
+	var b strings.Builder
+	b.WriteString("<html><body><div id='divcounter'>\n")
+	for _, id := range s.ids() {
+		fmt.Fprintf(&b, "<span class='xxy'>\n\t<a href='details.aspx?id=%d'  data-width='800' data-height='380'  class='html5lightbox'  adata-group='mygroup' >%s</a>\n</span>\n", id, html.EscapeString(s.name(id)))
+	}
+	for _, link := range s.Links {
+		fmt.Fprintf(&b, "<span class='xxy'>\n\t<a href='%s' class='html5lightbox'>%s</a>\n</span>\n", html.EscapeString(link), html.EscapeString(link))
+	}
+	b.WriteString("</div></body></html>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+func (s *Site) serveDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	if s.requests == nil {
+		s.requests = map[int]int{}
+	}
+	s.requests[id]++
+	attempt := s.requests[id]
+	s.mu.Unlock()
+
+	if attempt <= s.Flaky[id] {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if attempt <= s.Flaky[id]+s.RateLimited[id] {
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+
+	body, ok := s.Pages[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(body)
+}
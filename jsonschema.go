@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"AnitSayac_Scrapper/crawler/taxonomy"
+)
+
+// schemaVersion is the version of the published record format, stamped on
+// every incident as schema_version. Bump it whenever a JSON field of
+// Incident is added, removed, renamed or changes type; TestSchemaVersion
+// fails until then.
+const schemaVersion = 2
+
+// jsonSchema is the subset of JSON Schema (draft 2020-12) used to describe
+// the published formats. Fields are ordered as they read best.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           schemaProperties       `json:"properties,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// schemaProperty is a property of an object schema.
+type schemaProperty struct {
+	Name   string
+	Schema *jsonSchema
+}
+
+// schemaProperties keeps the properties of an object in struct order.
+type schemaProperties []schemaProperty
+
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, property := range p {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(property.Name)
+		value, err := json.Marshal(property.Schema)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func intPtr(n int) *int { return &n }
+
+// schemaDocs describes the published types and their fields, keyed by type
+// name and by "<type>.<json field>".
+var schemaDocs = map[string]string{
+	"Incident": "A woman killed, as listed on anitsayac.com.",
+
+	"Incident.schema_version":      "Version of this record format.",
+	"Incident.id":                  "Id of the incident on the site, the id in details.aspx?id=.",
+	"Incident.name":                "Name shown on the listing page.",
+	"Incident.fullname":            "Name given on the detail page.",
+	"Incident.age":                 "Age of the victim: a number of years, \"Reşit\" (adult) or \"Reşit Değil\" (minor) as the site gives it.",
+	"Incident.location":            "Location as written on the site, usually \"Province/District\".",
+	"Incident.province":            "Province resolved from location, empty when unresolved.",
+	"Incident.district":            "District resolved from location, empty when unknown.",
+	"Incident.province_code":       "Licence plate code of the province, 0 when unresolved (and for Northern Cyprus).",
+	"Incident.location_confidence": "Confidence of the location resolution, from 0 (unresolved) to 1 (exact).",
+	"Incident.date":                "Date as written on the site, normally dd/mm/yyyy.",
+	"Incident.date_iso":            "Date as yyyy-mm-dd, empty when the date is partial or invalid.",
+	"Incident.year":                "Year of the incident, 0 when unknown.",
+	"Incident.month":               "Month of the incident, 0 when unknown.",
+	"Incident.reason":              "Why she was killed (\"Neden öldürüldü\").",
+	"Incident.by":                  "Who killed her (\"Kim tarafından öldürüldü\").",
+	"Incident.by_relation":         "Canonical relation of the perpetrator from the taxonomy, several joined with \", \" when by names more than one; empty when by is empty or unmapped.",
+	"Incident.by_category":         "Category of the perpetrator's relation, that of the first one when by names more than one; empty when by is empty or unmapped.",
+	"Incident.protection":          "Whether she had a protection order (\"Korunma talebi\").",
+	"Incident.method":              "How she was killed (\"Öldürülme şekli\").",
+	"Incident.status":              "Status of the perpetrator (\"Failin durumu\").",
+	"Incident.source":              "News articles and other sources, null when the site lists none.",
+	"Incident.image":               "Image link, empty when there is none.",
+	"Incident.url":                 "Detail page on the site.",
+	"Incident.extra":               "Values of labels on the detail page the crawler doesn't know yet, keyed by label.",
+	"Incident.removed_at":          "When the incident was first found missing from the listing page; its other fields keep their last known values.",
+	"Incident.warnings":            "Problems found while parsing the detail page.",
+
+	"Warning":         "A problem found while parsing a field of a detail page.",
+	"Warning.field":   "Field the problem concerns.",
+	"Warning.message": "Description of the problem.",
+
+	"IncidentPage":                "A page of the incidents matching a query, ordered as requested.",
+	"IncidentPage.schema_version": "Version of the incident format.",
+	"IncidentPage.total":          "Number of matching incidents.",
+	"IncidentPage.page":           "1-based number of this page.",
+	"IncidentPage.per_page":       "Maximum number of incidents per page.",
+	"IncidentPage.pages":          "Number of pages.",
+	"IncidentPage.incidents":      "The incidents of this page.",
+
+	"ApiError":       "An error response.",
+	"ApiError.error": "What went wrong.",
+}
+
+// schemaConstraints narrows the types derived from the Go fields, keyed
+// like schemaDocs.
+var schemaConstraints = map[string]jsonSchema{
+	"Incident.schema_version":      {Const: schemaVersion},
+	"Incident.id":                  {Minimum: intPtr(1)},
+	"Incident.province_code":       {Minimum: intPtr(0), Maximum: intPtr(81)},
+	"Incident.location_confidence": {Minimum: intPtr(0), Maximum: intPtr(1)},
+	"Incident.date_iso":            {Pattern: `^(\d{4}-\d{2}-\d{2})?$`},
+	"Incident.year":                {Minimum: intPtr(0)},
+	"Incident.month":               {Minimum: intPtr(0), Maximum: intPtr(12)},
+	"Incident.by_category":         {Enum: append([]string{""}, taxonomy.Categories...)},
+	"Incident.removed_at":          {Format: "date-time"},
+}
+
+// schemaBuilder derives schemas from Go types. Named structs are collected
+// in defs and referred to by refPrefix and their name.
+type schemaBuilder struct {
+	defs      map[string]*jsonSchema
+	refPrefix string
+	// docs adds titles and descriptions; without them only what a change of
+	// the format affects is left.
+	docs bool
+}
+
+func newSchemaBuilder(refPrefix string, docs bool) *schemaBuilder {
+	return &schemaBuilder{defs: map[string]*jsonSchema{}, refPrefix: refPrefix, docs: docs}
+}
+
+// schemaName is the name of the schema of a struct, exported even when the
+// Go type isn't.
+func schemaName(t reflect.Type) string {
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+}
+
+// schema describes a Go type.
+func (b *schemaBuilder) schema(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Slice:
+		// A nil slice is encoded as null
+		return &jsonSchema{Type: []string{"array", "null"}, Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := b.defs[name]; !ok {
+			b.defs[name] = nil // breaks cycles
+			b.defs[name] = b.structSchema(t)
+		}
+		return &jsonSchema{Ref: b.refPrefix + name}
+	}
+	panic(fmt.Sprintf("no JSON Schema for %s", t))
+}
+
+func (b *schemaBuilder) structSchema(t reflect.Type) *jsonSchema {
+	s := &jsonSchema{Type: "object"}
+	if b.docs {
+		s.Description = schemaDocs[schemaName(t)]
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key := schemaName(t) + "." + name
+		property := b.schema(field.Type)
+		if constraint, ok := schemaConstraints[key]; ok {
+			property.Const = constraint.Const
+			property.Format = constraint.Format
+			property.Pattern = constraint.Pattern
+			property.Enum = constraint.Enum
+			property.Minimum = constraint.Minimum
+			property.Maximum = constraint.Maximum
+		}
+		if b.docs {
+			property.Description = schemaDocs[key]
+		}
+		if options == "omitempty" && property.Type != nil {
+			// Left out rather than null when empty
+			if types, ok := property.Type.([]string); ok {
+				property.Type = types[0]
+			}
+		}
+
+		s.Properties = append(s.Properties, schemaProperty{Name: name, Schema: property})
+		if options != "omitempty" {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// dataJSONSchema returns the JSON Schema of data.json. Without docs, titles
+// and descriptions are left out, leaving only what a change of the format
+// affects.
+func dataJSONSchema(docs bool) *jsonSchema {
+	b := newSchemaBuilder("#/$defs/", docs)
+	s := &jsonSchema{
+		Schema: "https://json-schema.org/draft/2020-12/schema",
+		Type:   "array",
+		Items:  b.schema(reflect.TypeOf(Incident{})),
+		Defs:   b.defs,
+	}
+	if docs {
+		s.Title = "Anıt Sayaç incidents"
+		s.Description = fmt.Sprintf("The incidents of data.json, format version %d. data.ndjson holds the same incidents, one per line.", schemaVersion)
+	}
+	return s
+}
+
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	format := fs.String("format", "json-schema", "document to print: json-schema, openapi")
+	out := fs.String("out", "", "output file (default stdout)")
+	fs.Parse(args)
+
+	var doc interface{}
+	switch *format {
+	case "json-schema":
+		doc = dataJSONSchema(true)
+	case "openapi":
+		doc = openAPIDocument()
+	default:
+		return fmt.Errorf("unknown format %q, expected one of: json-schema, openapi", *format)
+	}
+
+	if *out == "" {
+		return writeJSON(os.Stdout, doc)
+	}
+	return writeFile(*out, func(w io.Writer) error {
+		return writeJSON(w, doc)
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSchemaVersion fails when the JSON format of Incident changes without
+// a schemaVersion bump. Each version's format is kept in
+// testdata/schema/v<version>.json; -update only creates the file of a new
+// version and never rewrites an existing one.
+func TestSchemaVersion(t *testing.T) {
+	got, err := json.MarshalIndent(dataJSONSchema(false), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	file := filepath.Join("testdata", "schema", fmt.Sprintf("v%d.json", schemaVersion))
+	want, err := os.ReadFile(file)
+	if os.IsNotExist(err) && *update {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("%s (run with -update after bumping schemaVersion to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("the JSON format of Incident no longer matches version %d in %s: bump schemaVersion, run go test . -update and describe the change in the README\ngot:\n%s", schemaVersion, file, got)
+	}
+}
+
+// TestPublishedSchemas keeps the documents in schema/ in sync with the code.
+func TestPublishedSchemas(t *testing.T) {
+	for file, doc := range map[string]interface{}{
+		"data.schema.json": dataJSONSchema(true),
+		"openapi.json":     openAPIDocument(),
+	} {
+		got, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareGolden(t, filepath.Join("schema", file), append(got, '\n'))
+	}
+}
+
+func TestSchemaDocs(t *testing.T) {
+	schemas := dataJSONSchema(true).Defs
+	for name, s := range openAPIDocument().Components.Schemas {
+		schemas[name] = s
+	}
+	for name, s := range schemas {
+		if s.Description == "" {
+			t.Errorf("%s has no description in schemaDocs", name)
+		}
+		for _, property := range s.Properties {
+			if property.Schema.Description == "" {
+				t.Errorf("%s.%s has no description in schemaDocs", name, property.Name)
+			}
+		}
+	}
+}
+
+// TestSchemaMatchesOutput checks the incidents of the end-to-end golden
+// against the properties of the schema.
+func TestSchemaMatchesOutput(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "e2e", "data.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var incidents []map[string]interface{}
+	if err := json.Unmarshal(content, &incidents); err != nil {
+		t.Fatal(err)
+	}
+
+	s := dataJSONSchema(false).Defs["Incident"]
+	properties := map[string]bool{}
+	for _, property := range s.Properties {
+		properties[property.Name] = true
+	}
+	for _, incident := range incidents {
+		for name := range incident {
+			if !properties[name] {
+				t.Errorf("incident %v: %s is not in the schema", incident["id"], name)
+			}
+		}
+		for _, name := range s.Required {
+			if _, ok := incident[name]; !ok {
+				t.Errorf("incident %v: required %s is missing", incident["id"], name)
+			}
+		}
+		if v := incident["schema_version"]; v != float64(schemaVersion) {
+			t.Errorf("incident %v: schema_version %v, want %d", incident["id"], v, schemaVersion)
+		}
+	}
+}
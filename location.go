@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"AnitSayac_Scrapper/crawler/gazetteer"
+	"AnitSayac_Scrapper/crawler/parser"
+)
+
+var locations = gazetteer.Default()
+
+// resolveLocation fills the structured location fields from Location and
+// records a warning when a non-empty value can't be resolved.
+func resolveLocation(incident Incident) Incident {
+	m := locations.Resolve(incident.Location)
+	incident.Province = m.Province
+	incident.District = m.District
+	incident.ProvinceCode = m.ProvinceCode
+	incident.LocationConfidence = m.Confidence
+
+	incident.Warnings = withoutField(incident.Warnings, "location")
+	if incident.Location != "" && !m.Resolved() {
+		incident.Warnings = append(incident.Warnings, parser.Warning{
+			Field:   "location",
+			Message: fmt.Sprintf("unresolved location %q", incident.Location),
+		})
+	}
+	return incident
+}
+
+// reportUnresolvedLocations logs every distinct location that couldn't be
+// matched against the gazetteer, most frequent first.
+func reportUnresolvedLocations(incidents []Incident) {
+	counts := map[string]int{}
+	for _, incident := range incidents {
+		if incident.Location != "" && incident.Province == "" {
+			counts[incident.Location]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	unresolved := make([]string, 0, len(counts))
+	for location := range counts {
+		unresolved = append(unresolved, location)
+	}
+	sort.Slice(unresolved, func(i, j int) bool {
+		if counts[unresolved[i]] != counts[unresolved[j]] {
+			return counts[unresolved[i]] > counts[unresolved[j]]
+		}
+		return unresolved[i] < unresolved[j]
+	})
+
+	log.Printf("%d unresolved location(s):", len(unresolved))
+	for _, location := range unresolved {
+		log.Printf("  %q (%d)", location, counts[location])
+	}
+}
+
+// withoutField drops the warnings about field.
+func withoutField(warnings []parser.Warning, field string) []parser.Warning {
+	var kept []parser.Warning
+	for _, w := range warnings {
+		if w.Field != field {
+			kept = append(kept, w)
+		}
+	}
+	return kept
+}
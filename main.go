@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"AnitSayac_Scrapper/crawler/parser"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"crawl", "crawl [flags]", "crawl the site and update data.json and data.csv (the default)", runCrawl},
+	{"fetch", "fetch [flags] <id>", "fetch and print a single incident", runFetch},
+	{"parse", "parse [flags] <file.html>", "parse a saved detail page", runParse},
+	{"export", "export [flags]", "convert data.json to another format", runExport},
+	{"validate", "validate [flags]", "check data.json and data.csv", runValidate},
+	{"diff", "diff [flags] <old.json> <new.json>", "report added, removed and modified incidents", runDiff},
+	{"history", "history [flags] <id>", "print the recorded changes of an incident", runHistory},
+	{"serve", "serve [flags]", "serve a read-only query API over data.json", runServe},
+	{"stats", "stats [flags]", "count incidents by year, province, method, perpetrator and protection", runStats},
+	{"relations", "relations [flags]", "report how the perpetrators of data.json map to the relation taxonomy", runRelations},
+	{"report", "report [flags]", "generate a static HTML report with charts from data.json", runReport},
+	{"schema", "schema [flags]", "print the JSON Schema of data.json or the OpenAPI document of serve", runSchema},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-28s %s\n", c.usage, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+func main() {
+	args := os.Args[1:]
+
+	// Without a command the binary crawls, as it always has
+	name := "crawl"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				log.Fatalf("%s: %s\n", name, err)
+			}
+			return
+		}
+	}
+
+	if name != "help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	}
+	usage()
+	os.Exit(2)
+}
+
+// addSiteFlags registers the flags selecting the site and response cache.
+// Their defaults can also be set through the environment so the crawler
+// can be pointed at a mirror without changing the command line.
+func addSiteFlags(fs *flag.FlagSet) {
+	fs.StringVar(&baseUrl, "base-url", envOr("ANITSAYAC_BASE_URL", baseUrl), "base URL of the site ($ANITSAYAC_BASE_URL)")
+	fs.StringVar(&allowedDomains, "allowed-domains", envOr("ANITSAYAC_ALLOWED_DOMAINS", allowedDomains), "comma-separated domains the crawler may visit, default the host of -base-url ($ANITSAYAC_ALLOWED_DOMAINS)")
+	fs.StringVar(&cacheDir, "cache-dir", envOr("ANITSAYAC_CACHE_DIR", cacheDir), "directory caching fetched pages ($ANITSAYAC_CACHE_DIR)")
+}
+
+// addCrawlFlags registers the flags controlling how pages are fetched and
+// parsed. The returned function finishes setting up opts once the flags
+// have been parsed.
+func addCrawlFlags(fs *flag.FlagSet, opts *crawlOptions) func() error {
+	addSiteFlags(fs)
+	fs.IntVar(&opts.Parallelism, "parallelism", 4, "maximum number of concurrent detail page requests")
+	fs.DurationVar(&opts.Delay, "delay", 100*time.Millisecond, "delay between detail page requests per worker")
+	fs.DurationVar(&opts.RandomDelay, "random-delay", 200*time.Millisecond, "maximum random jitter added to -delay")
+	fs.IntVar(&opts.Retry.MaxAttempts, "retries", 4, "maximum number of attempts per page")
+	fs.DurationVar(&opts.Retry.BaseDelay, "retry-delay", time.Second, "delay before the first retry, doubled on every further attempt")
+	fs.DurationVar(&opts.Retry.MaxDelay, "retry-max-delay", 30*time.Second, "upper bound for the delay between retries")
+	newParser := addParserFlags(fs)
+
+	return func() error {
+		baseUrl = strings.TrimSuffix(baseUrl, "/")
+		if u, err := url.Parse(baseUrl); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid -base-url %q, expected e.g. https://anitsayac.com", baseUrl)
+		}
+		if opts.Parallelism < 1 {
+			return fmt.Errorf("-parallelism must be at least 1, got %d", opts.Parallelism)
+		}
+		if opts.Retry.MaxAttempts < 1 {
+			return fmt.Errorf("-retries must be at least 1, got %d", opts.Retry.MaxAttempts)
+		}
+		if opts.Retry.BaseDelay <= 0 {
+			return fmt.Errorf("-retry-delay must be positive, got %s", opts.Retry.BaseDelay)
+		}
+		if opts.Retry.MaxDelay < opts.Retry.BaseDelay {
+			return fmt.Errorf("-retry-max-delay must be at least -retry-delay %s, got %s", opts.Retry.BaseDelay, opts.Retry.MaxDelay)
+		}
+
+		p, err := newParser()
+		if err != nil {
+			return err
+		}
+		opts.Parser = p
+		return nil
+	}
+}
+
+// addParserFlags registers the flags configuring the detail page parser and
+// returns a function building it once the flags have been parsed.
+func addParserFlags(fs *flag.FlagSet) func() (*parser.Parser, error) {
+	correctionsFile := fs.String("corrections", "", "path to a corrections file overriding the embedded one")
+	imageHost := fs.String("image-host", envOr("ANITSAYAC_IMAGE_HOST", ""), "base URL replacing https://"+parser.DefaultImageHost+" in image links ($ANITSAYAC_IMAGE_HOST)")
+
+	return func() (*parser.Parser, error) {
+		corrections := parser.DefaultCorrections()
+		if *correctionsFile != "" {
+			loaded, err := parser.LoadCorrectionsFile(*correctionsFile)
+			if err != nil {
+				return nil, fmt.Errorf("cannot load corrections: %w", err)
+			}
+			corrections = loaded
+		}
+
+		p := parser.New(corrections)
+		p.ImageBase = *imageHost
+		return p, nil
+	}
+}
+
+// envOr returns the value of the environment variable key, or fallback if
+// it is unset or empty.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ndjsonWriter streams incidents to a file as newline-delimited JSON. Each
+// incident is written through as soon as it is added, so a crash loses at
+// most the line being written.
+type ndjsonWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// createNDJSON creates or truncates the file at path.
+func createNDJSON(path string) (*ndjsonWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ndjsonWriter{file: file}, nil
+}
+
+// Write appends an incident. It is safe for concurrent use.
+func (w *ndjsonWriter) Write(incident Incident) error {
+	line, err := json.Marshal(incident)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close flushes the file to disk and closes it.
+func (w *ndjsonWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// readNDJSON reads the incidents of a newline-delimited JSON file. A last
+// line without its newline was cut short by a crash and is skipped.
+func readNDJSON(path string) ([]Incident, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var incidents []Incident
+	r := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				log.Printf("%s:%d: skipping incomplete last line", path, n)
+			}
+			return incidents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var incident Incident
+		if err := json.Unmarshal(line, &incident); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		incidents = append(incidents, incident)
+	}
+}
+
+// writeNDJSON writes incidents as newline-delimited JSON, one incident per
+// line.
+func writeNDJSON(w io.Writer, incidents []Incident) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, incident := range incidents {
+		if err := enc.Encode(incident); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// partialFileName returns the file detail pages are streamed to while
+// crawling into jsonFile, e.g. data.partial.ndjson for data.json.
+func partialFileName(jsonFile string) string {
+	return strings.TrimSuffix(jsonFile, ".json") + ".partial.ndjson"
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadNDJSONSkipsIncompleteLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.partial.ndjson")
+	w, err := createNDJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Incident{{Id: 1, Name: "A"}, {Id: 2, Name: "B", Source: []string{"https://a"}}}
+	for _, incident := range want {
+		if err := w.Write(incident); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash in the middle of the next line
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"id":3,"na`)
+	file.Close()
+
+	got, err := readNDJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readNDJSON() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// openAPI is the subset of an OpenAPI 3.1 document describing serve.
+type openAPI struct {
+	OpenAPI    string                 `json:"openapi"`
+	Info       openAPIInfo            `json:"info"`
+	Paths      map[string]openAPIPath `json:"paths"`
+	Components openAPIComponents      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPIPath struct {
+	Get openAPIOperation `json:"get"`
+}
+
+type openAPIOperation struct {
+	OperationId string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *jsonSchema `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]openAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Description string      `json:"description"`
+	Schema      *jsonSchema `json:"schema"`
+}
+
+type openAPIMediaType struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*jsonSchema `json:"schemas"`
+}
+
+// incidentQueryParameters documents the query string read by
+// parseIncidentQuery.
+func incidentQueryParameters() []openAPIParameter {
+	integer := func(minimum int) *jsonSchema { return &jsonSchema{Type: "integer", Minimum: intPtr(minimum)} }
+	values := func() *jsonSchema {
+		return &jsonSchema{Type: "array", Items: &jsonSchema{Type: "string"}}
+	}
+	text := func(name, field string) openAPIParameter {
+		return openAPIParameter{
+			Name:        name,
+			In:          "query",
+			Description: fmt.Sprintf("Only incidents whose %s is one of these values, ignoring case and Turkish diacritics. Repeat the parameter or separate values with commas.", field),
+			Schema:      values(),
+		}
+	}
+
+	return []openAPIParameter{
+		{Name: "year", In: "query", Description: "Only incidents of this year; overrides year_from and year_to.", Schema: integer(0)},
+		{Name: "year_from", In: "query", Description: "Only incidents of this year or later.", Schema: integer(0)},
+		{Name: "year_to", In: "query", Description: "Only incidents of this year or earlier; incidents of an unknown year are left out.", Schema: integer(0)},
+		text("province", "province"),
+		text("district", "district"),
+		text("method", "method"),
+		text("by", "perpetrator (by)"),
+		text("by_category", "perpetrator category, e.g. former_partner"),
+		text("status", "perpetrator status"),
+		text("age", "age as written on the site, e.g. Reşit"),
+		{Name: "age_min", In: "query", Description: "Only incidents with a numeric age of at least this many years.", Schema: integer(0)},
+		{Name: "age_max", In: "query", Description: "Only incidents with a numeric age of at most this many years.", Schema: integer(0)},
+		{Name: "removed", In: "query", Description: "Whether incidents no longer listed on the site are left out, included or the only ones returned.", Schema: &jsonSchema{Type: "string", Enum: []string{"exclude", "include", "only"}, Default: "exclude"}},
+		{Name: "sort", In: "query", Description: "Field to sort by, prefixed with - for descending order. Ties are broken by id.", Schema: &jsonSchema{Type: "string", Enum: sortValues(), Default: "id"}},
+		{Name: "page", In: "query", Description: "1-based page number.", Schema: &jsonSchema{Type: "integer", Minimum: intPtr(1), Default: 1}},
+		{Name: "per_page", In: "query", Description: "Number of incidents per page.", Schema: &jsonSchema{Type: "integer", Minimum: intPtr(1), Maximum: intPtr(maxPerPage), Default: defaultPerPage}},
+	}
+}
+
+// sortValues lists every accepted value of the sort parameter.
+func sortValues() []string {
+	var values []string
+	for _, name := range sortNames() {
+		values = append(values, name, "-"+name)
+	}
+	return values
+}
+
+// openAPIDocument describes the API of the serve command.
+func openAPIDocument() openAPI {
+	b := newSchemaBuilder("#/components/schemas/", true)
+	ref := func(v interface{}) *jsonSchema { return b.schema(reflect.TypeOf(v)) }
+
+	validators := map[string]openAPIHeader{
+		"ETag":          {Description: "Changes whenever the served dataset changes.", Schema: &jsonSchema{Type: "string"}},
+		"Last-Modified": {Description: "Modification time of the served dataset.", Schema: &jsonSchema{Type: "string"}},
+	}
+	ok := func(description string, schema *jsonSchema) openAPIResponse {
+		return openAPIResponse{
+			Description: description,
+			Headers:     validators,
+			Content:     map[string]openAPIMediaType{"application/json": {Schema: schema}},
+		}
+	}
+	failure := func(description string) openAPIResponse {
+		return openAPIResponse{
+			Description: description,
+			Content:     map[string]openAPIMediaType{"application/json": {Schema: ref(apiError{})}},
+		}
+	}
+	notModified := openAPIResponse{Description: "The dataset hasn't changed since the If-None-Match or If-Modified-Since validator."}
+
+	return openAPI{
+		OpenAPI: "3.1.0",
+		Info: openAPIInfo{
+			Title:       "Anıt Sayaç incidents",
+			Description: "Read-only queries over data.json, served by the serve command.",
+			Version:     fmt.Sprint(schemaVersion),
+		},
+		Paths: map[string]openAPIPath{
+			"/incidents": {Get: openAPIOperation{
+				OperationId: "listIncidents",
+				Summary:     "List the incidents matching every given filter, one page at a time.",
+				Parameters:  incidentQueryParameters(),
+				Responses: map[string]openAPIResponse{
+					"200": ok("A page of matching incidents.", ref(incidentPage{})),
+					"304": notModified,
+					"400": failure("Invalid query parameter."),
+				},
+			}},
+			"/incidents/{id}": {Get: openAPIOperation{
+				OperationId: "getIncident",
+				Summary:     "Get a single incident.",
+				Parameters: []openAPIParameter{
+					{Name: "id", In: "path", Description: "Id of the incident on the site.", Required: true, Schema: &jsonSchema{Type: "integer"}},
+				},
+				Responses: map[string]openAPIResponse{
+					"200": ok("The incident.", ref(Incident{})),
+					"304": notModified,
+					"404": failure("No incident with this id."),
+				},
+			}},
+		},
+		Components: openAPIComponents{Schemas: b.defs},
+	}
+}
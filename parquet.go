@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetIncident is the row type of the Parquet export. Column names match
+// the JSON fields. Zero values of optional columns are written as null, so
+// unknown values are null rather than empty or zero.
+type parquetIncident struct {
+	Id       int64  `parquet:"id"`
+	Name     string `parquet:"name,optional"`
+	FullName string `parquet:"fullname,optional"`
+	Age      string `parquet:"age,optional"`
+	Location string `parquet:"location,optional"`
+
+	Province           string  `parquet:"province,optional"`
+	District           string  `parquet:"district,optional"`
+	ProvinceCode       *int32  `parquet:"province_code,optional"`
+	LocationConfidence float64 `parquet:"location_confidence,optional"`
+
+	Date       string   `parquet:"date,optional"`
+	DateISO    int32    `parquet:"date_iso,optional,date"`
+	Year       int32    `parquet:"year,optional"`
+	Month      int32    `parquet:"month,optional"`
+	Reason     string   `parquet:"reason,optional"`
+	By         string   `parquet:"by,optional"`
+	ByRelation string   `parquet:"by_relation,optional"`
+	ByCategory string   `parquet:"by_category,optional"`
+	Protection string   `parquet:"protection,optional"`
+	Method     string   `parquet:"method,optional"`
+	Status     string   `parquet:"status,optional"`
+	Source     []string `parquet:"source,list"`
+	Image      string   `parquet:"image,optional"`
+	Url        string   `parquet:"url,optional"`
+
+	RemovedAt int64             `parquet:"removed_at,optional,timestamp(millisecond)"`
+	Extra     map[string]string `parquet:"extra,optional"`
+
+	SchemaVersion int32 `parquet:"schema_version"`
+}
+
+// writeParquet writes incidents as a typed, zstd compressed Parquet file.
+func writeParquet(w io.Writer, incidents []Incident) error {
+	rows := make([]parquetIncident, len(incidents))
+	for i, incident := range incidents {
+		rows[i] = newParquetIncident(incident)
+	}
+	return parquet.Write(w, rows, parquet.Compression(&parquet.Zstd))
+}
+
+func newParquetIncident(incident Incident) parquetIncident {
+	row := parquetIncident{
+		Id:                 int64(incident.Id),
+		Name:               incident.Name,
+		FullName:           incident.FullName,
+		Age:                incident.Age,
+		Location:           incident.Location,
+		Province:           incident.Province,
+		District:           incident.District,
+		LocationConfidence: incident.LocationConfidence,
+		Date:               incident.Date,
+		Year:               int32(incident.Year),
+		Month:              int32(incident.Month),
+		Reason:             incident.Reason,
+		By:                 incident.By,
+		ByRelation:         incident.ByRelation,
+		ByCategory:         incident.ByCategory,
+		Protection:         incident.Protection,
+		Method:             incident.Method,
+		Status:             incident.Status,
+		Source:             incident.Source,
+		Image:              incident.Image,
+		Url:                incident.Url,
+		Extra:              incident.Extra,
+		SchemaVersion:      int32(incident.SchemaVersion),
+	}
+
+	// Plate code 0 is Northern Cyprus, so only a resolved province tells
+	// whether the code is known
+	if incident.Province != "" {
+		code := int32(incident.ProvinceCode)
+		row.ProvinceCode = &code
+	}
+
+	if date, err := time.Parse("2006-01-02", incident.DateISO); err == nil {
+		row.DateISO = int32(date.Unix() / (24 * 60 * 60))
+	}
+	if removedAt, err := time.Parse(time.RFC3339, incident.RemovedAt); err == nil {
+		row.RemovedAt = removedAt.UnixMilli()
+	}
+	return row
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestWriteParquet(t *testing.T) {
+	incidents := []Incident{
+		{
+			Id: 35697, Name: "Selma Çiftçi", Location: "Mersin", Province: "Mersin", ProvinceCode: 33, LocationConfidence: 1,
+			Date: "04/04/2024", DateISO: "2024-04-04", Year: 2024, Month: 4, Status: "Tutuklu",
+			Source: []string{"https://a", "https://b"}, RemovedAt: "2024-10-18T00:00:00Z",
+		},
+		{Id: 2, Location: "Girne", Province: "Kıbrıs"},
+		{Id: 3},
+	}
+
+	var b bytes.Buffer
+	if err := writeParquet(&b, incidents); err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for column, want := range map[string]string{
+		"id":            "INT(64,true)",
+		"date_iso":      "DATE",
+		"year":          "INT(32,true)",
+		"province_code": "INT(32,true)",
+		"status":        "STRING",
+		"removed_at":    "TIMESTAMP(isAdjustedToUTC=true,unit=MILLIS)",
+	} {
+		leaf, ok := file.Schema().Lookup(column)
+		if !ok {
+			t.Errorf("column %s is missing", column)
+		} else if got := leaf.Node.Type().String(); got != want {
+			t.Errorf("column %s has type %s, want %s", column, got, want)
+		}
+	}
+
+	rows := make([]parquet.Row, len(incidents))
+	reader := file.RowGroups()[0].Rows()
+	defer reader.Close()
+	if n, err := reader.ReadRows(rows); n != len(rows) || (err != nil && err != io.EOF) {
+		t.Fatalf("read %d rows: %v", n, err)
+	}
+
+	// value returns the first value of column in row, nil when null
+	value := func(row parquet.Row, column string) interface{} {
+		leaf, _ := file.Schema().Lookup(strings.Split(column, ".")...)
+		for _, v := range row {
+			if v.Column() == leaf.ColumnIndex {
+				if v.IsNull() {
+					return nil
+				}
+				return v.String()
+			}
+		}
+		t.Fatalf("no value for column %s", column)
+		return nil
+	}
+
+	tests := []struct {
+		row    int
+		column string
+		want   interface{}
+	}{
+		{0, "id", "35697"},
+		{0, "date_iso", "19817"}, // 2024-04-04 in days since the epoch
+		{0, "source.list.element", "https://a"},
+		{0, "removed_at", "1729209600000"},
+		{1, "province_code", "0"}, // Kıbrıs
+		{1, "date_iso", nil},
+		{2, "province_code", nil},
+		{2, "name", nil},
+		{2, "year", nil},
+		{2, "source.list.element", nil},
+	}
+	for _, tt := range tests {
+		if got := value(rows[tt.row], tt.column); got != tt.want {
+			t.Errorf("row %d %s = %v, want %v", tt.row, tt.column, got, tt.want)
+		}
+	}
+}
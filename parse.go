@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"AnitSayac_Scrapper/crawler/parser"
+)
+
+// runParse parses a saved detail page and prints the result as JSON.
+func runParse(args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ExitOnError)
+	pageUrl := fs.String("url", baseUrl+"/details.aspx", "address the page was saved from, used to resolve relative image paths")
+	newParser := addParserFlags(fs)
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("expected a single HTML file")
+	}
+
+	p, err := newParser()
+	if err != nil {
+		return err
+	}
+
+	body, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	detail, warnings, err := p.ParseDetail(body, *pageUrl)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(os.Stdout, struct {
+		Detail   parser.Detail    `json:"detail"`
+		Warnings []parser.Warning `json:"warnings"`
+	}{detail, warnings})
+}
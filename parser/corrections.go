@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Corrections for misspellings observed on the site. Values are matched
+// exactly against the trimmed field text.
+//
+//go:embed corrections.json
+var defaultCorrections []byte
+
+// Fields whose values are passed through the correction dictionaries.
+var correctableFields = []string{"age", "location", "reason", "by", "protection", "method", "status"}
+
+// Dictionary maps a misspelled value to its correction.
+type Dictionary map[string]string
+
+// Corrections is a versioned set of correction dictionaries together with
+// the order in which they are applied to each field.
+type Corrections struct {
+	Version int `json:"version"`
+	// Apply lists, per field, the dictionaries applied in order.
+	Apply        map[string][]string   `json:"apply"`
+	Dictionaries map[string]Dictionary `json:"dictionaries"`
+}
+
+// DefaultCorrections returns the corrections embedded in the binary.
+func DefaultCorrections() *Corrections {
+	c, err := LoadCorrections(bytes.NewReader(defaultCorrections))
+	if err != nil {
+		panic("parser: invalid embedded corrections: " + err.Error())
+	}
+	return c
+}
+
+// LoadCorrectionsFile reads and validates a corrections file.
+func LoadCorrectionsFile(path string) (*Corrections, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	c, err := LoadCorrections(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// LoadCorrections decodes and validates corrections from r.
+func LoadCorrections(r io.Reader) (*Corrections, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	c := &Corrections{}
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("parser: invalid corrections: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Correct passes value through every dictionary that applies to field.
+func (c *Corrections) Correct(field, value string) string {
+	for _, name := range c.Apply[field] {
+		if corrected, ok := c.Dictionaries[name][value]; ok {
+			value = strings.TrimSpace(corrected)
+		}
+	}
+	return value
+}
+
+// Validate checks that every field and dictionary reference is known and
+// that no field is subject to conflicting or cyclic mappings.
+func (c *Corrections) Validate() error {
+	if c.Version < 1 {
+		return fmt.Errorf("parser: corrections version must be at least 1, got %d", c.Version)
+	}
+
+	var problems []string
+	for field, names := range c.Apply {
+		if !isCorrectableField(field) {
+			problems = append(problems, fmt.Sprintf("unknown field %q", field))
+			continue
+		}
+
+		// Union of every mapping applied to the field, to spot conflicts
+		// between dictionaries and mapping cycles.
+		targets := map[string]string{}
+		origin := map[string]string{}
+		for _, name := range names {
+			dictionary, ok := c.Dictionaries[name]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown dictionary %q", field, name))
+				continue
+			}
+			for from, to := range dictionary {
+				if previous, ok := targets[from]; ok && previous != to {
+					problems = append(problems, fmt.Sprintf("%s: %q maps to %q in %q but to %q in %q", field, from, previous, origin[from], to, name))
+					continue
+				}
+				targets[from] = to
+				origin[from] = name
+			}
+		}
+
+		for from := range targets {
+			// Report each cycle once, starting from its smallest member
+			if cycle := findCycle(targets, from); cycle != nil && from == minString(cycle) {
+				problems = append(problems, fmt.Sprintf("%s: mapping cycle %s", field, strings.Join(quoteAll(cycle), " -> ")))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("parser: invalid corrections:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// findCycle follows mappings from start and returns the cycle that leads
+// back to start, or nil if there is none.
+func findCycle(targets map[string]string, start string) []string {
+	path := []string{start}
+	seen := map[string]bool{start: true}
+	for current := start; ; {
+		next, ok := targets[current]
+		if !ok {
+			return nil
+		}
+		path = append(path, next)
+		if next == start {
+			return path
+		}
+		if seen[next] {
+			// Cycle that doesn't include start, reported from its own member
+			return nil
+		}
+		seen[next] = true
+		current = next
+	}
+}
+
+// UnmarshalJSON decodes a dictionary, rejecting a misspelling that is
+// listed twice with different corrections instead of silently keeping the
+// last one.
+func (d *Dictionary) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("dictionary must be an object, got %v", tok)
+	}
+
+	*d = Dictionary{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		from := tok.(string)
+
+		var to string
+		if err := dec.Decode(&to); err != nil {
+			return fmt.Errorf("%q: %w", from, err)
+		}
+		if previous, ok := (*d)[from]; ok && previous != to {
+			return fmt.Errorf("%q maps to both %q and %q", from, previous, to)
+		}
+		(*d)[from] = to
+	}
+
+	_, err := dec.Token()
+	return err
+}
+
+func isCorrectableField(field string) bool {
+	for _, f := range correctableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+func minString(values []string) string {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type correctionCase struct {
+	Field string `json:"field"`
+	Raw   string `json:"raw"`
+	Want  string `json:"want"`
+}
+
+var fieldLabels = map[string]string{
+	"age":        "Maktülün yaşı",
+	"location":   "İl/ilçe",
+	"reason":     "Neden öldürüldü",
+	"by":         "Kim tarafından öldürüldü",
+	"protection": "Korunma talebi",
+	"method":     "Öldürülme şekli",
+	"status":     "Failin durumu",
+}
+
+func loadCorrectionCases(t *testing.T) []correctionCase {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", "corrections_cases.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases []correctionCase
+	if err := json.Unmarshal(content, &cases); err != nil {
+		t.Fatal(err)
+	}
+	return cases
+}
+
+func fieldValue(d Detail, field string) string {
+	switch field {
+	case "age":
+		return d.Age
+	case "location":
+		return d.Location
+	case "reason":
+		return d.Reason
+	case "by":
+		return d.By
+	case "protection":
+		return d.Protection
+	case "method":
+		return d.Method
+	case "status":
+		return d.Status
+	}
+	return ""
+}
+
+// TestCorrectionCases parses a page carrying each recorded raw value and
+// checks the corrected result.
+func TestCorrectionCases(t *testing.T) {
+	for _, c := range loadCorrectionCases(t) {
+		page := "<b>Ad Soyad:</b> X<br><b>" + fieldLabels[c.Field] + ": </b>" + c.Raw + "<br>"
+		detail, _, err := ParseDetail([]byte(page), "https://anitsayac.com/details.aspx?id=1")
+		if err != nil {
+			t.Fatalf("%s %q: %v", c.Field, c.Raw, err)
+		}
+		if got := fieldValue(detail, c.Field); got != c.Want {
+			t.Errorf("%s %q: got %q, want %q", c.Field, c.Raw, got, c.Want)
+		}
+	}
+}
+
+// TestEveryCorrectionExercised makes sure no dictionary entry is dead: each
+// one must be hit by a case for a field the dictionary applies to.
+func TestEveryCorrectionExercised(t *testing.T) {
+	corrections := DefaultCorrections()
+
+	covered := map[string]map[string]bool{}
+	for _, c := range loadCorrectionCases(t) {
+		for _, name := range corrections.Apply[c.Field] {
+			if covered[name] == nil {
+				covered[name] = map[string]bool{}
+			}
+			covered[name][c.Raw] = true
+		}
+	}
+
+	for name, dictionary := range corrections.Dictionaries {
+		for from := range dictionary {
+			if !covered[name][from] {
+				t.Errorf("dictionary %q entry %q is not exercised by testdata/corrections_cases.json", name, from)
+			}
+		}
+	}
+}
+
+func TestLoadCorrectionsRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"version", `{"version": 0}`, "version"},
+		{"unknown field", `{"version": 1, "apply": {"name": ["all"]}, "dictionaries": {"all": {}}}`, `unknown field "name"`},
+		{"unknown dictionary", `{"version": 1, "apply": {"age": ["missing"]}}`, `unknown dictionary "missing"`},
+		{"duplicate entry", `{"version": 1, "dictionaries": {"all": {"a": "b", "a": "c"}}}`, `"a" maps to both "b" and "c"`},
+		{"conflict", `{"version": 1, "apply": {"age": ["age", "all"]}, "dictionaries": {"age": {"a": "b"}, "all": {"a": "c"}}}`, `age: "a" maps to "b" in "age" but to "c" in "all"`},
+		{"cycle", `{"version": 1, "apply": {"by": ["by", "all"]}, "dictionaries": {"by": {"a": "b"}, "all": {"b": "a"}}}`, `by: mapping cycle "a" -> "b" -> "a"`},
+		{"self mapping", `{"version": 1, "apply": {"age": ["all"]}, "dictionaries": {"all": {"a": "a"}}}`, `age: mapping cycle "a" -> "a"`},
+	}
+
+	for _, tt := range tests {
+		_, err := LoadCorrections(strings.NewReader(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got err %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
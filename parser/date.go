@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date is the structured form of the site's "Tarih" value.
+//
+// Policy for dates that aren't a complete dd/mm/yyyy value:
+//   - day, month and year separated by "/", "." or "-" (with stray spaces or
+//     zero padding) are normalized, with a warning
+//   - partial dates ("mm/yyyy", "yyyy", or a zero day/month) keep the known
+//     Year and Month but leave ISO empty, with a warning
+//   - anything else, including impossible calendar dates, leaves every
+//     field empty, with a warning
+type Date struct {
+	// ISO is the date as yyyy-mm-dd, empty unless the day is known.
+	ISO string
+	// Year and Month are 0 when unknown.
+	Year  int
+	Month int
+}
+
+var (
+	canonicalDateRe = regexp.MustCompile(`^\d{2}/\d{2}/\d{4}$`)
+	// Days and months have one or two digits, sometimes after a stray
+	// leading zero as in 05/010/2025
+	fullDateRe  = regexp.MustCompile(`^0?(\d{1,2})\s*[/.\-]\s*0?(\d{1,2})\s*[/.\-]\s*(\d{4})$`)
+	monthDateRe = regexp.MustCompile(`^0?(\d{1,2})\s*[/.\-]\s*(\d{4})$`)
+	yearDateRe  = regexp.MustCompile(`^(\d{4})$`)
+)
+
+// ParseDate parses a raw "Tarih" value. The returned message is empty for a
+// complete dd/mm/yyyy date and otherwise explains what was normalized or
+// why parts of the date are missing.
+func ParseDate(raw string) (Date, string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Date{}, "empty date"
+	}
+
+	var day, month, year int
+	if m := fullDateRe.FindStringSubmatch(raw); m != nil {
+		day, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		year, _ = strconv.Atoi(m[3])
+	} else if m := monthDateRe.FindStringSubmatch(raw); m != nil {
+		month, _ = strconv.Atoi(m[1])
+		year, _ = strconv.Atoi(m[2])
+	} else if m := yearDateRe.FindStringSubmatch(raw); m != nil {
+		year, _ = strconv.Atoi(m[1])
+	} else {
+		return Date{}, fmt.Sprintf("unrecognized date %q", raw)
+	}
+
+	if month > 12 || day > 31 {
+		return Date{}, fmt.Sprintf("invalid date %q", raw)
+	}
+	if month == 0 {
+		return Date{Year: year}, fmt.Sprintf("partial date %q, only the year is known", raw)
+	}
+	if day == 0 {
+		return Date{Year: year, Month: month}, fmt.Sprintf("partial date %q, the day is unknown", raw)
+	}
+
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day {
+		return Date{}, fmt.Sprintf("invalid date %q", raw)
+	}
+
+	date := Date{ISO: t.Format("2006-01-02"), Year: year, Month: month}
+	if !canonicalDateRe.MatchString(raw) {
+		return date, fmt.Sprintf("non-standard date %q normalized to %s", raw, date.ISO)
+	}
+	return date, ""
+}
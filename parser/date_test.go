@@ -0,0 +1,48 @@
+package parser
+
+import "testing"
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		raw         string
+		want        Date
+		wantProblem bool
+	}{
+		{"16/10/2024", Date{ISO: "2024-10-16", Year: 2024, Month: 10}, false},
+		{"04.07.2017", Date{ISO: "2017-07-04", Year: 2017, Month: 7}, true},
+		{"7/01/2012", Date{ISO: "2012-01-07", Year: 2012, Month: 1}, true},
+		{"05/010/2025", Date{ISO: "2025-10-05", Year: 2025, Month: 10}, true},
+		{"26/07/ 2010", Date{ISO: "2010-07-26", Year: 2010, Month: 7}, true},
+		{"00/05/2010", Date{Year: 2010, Month: 5}, true},
+		{"05/2010", Date{Year: 2010, Month: 5}, true},
+		{"2010", Date{Year: 2010}, true},
+		{"31/02/2020", Date{}, true},
+		{"13/13/2020", Date{}, true},
+		{"123.01.2020", Date{}, true},
+		{"01/123/2020", Date{}, true},
+		{"123/2020", Date{}, true},
+		{"Tespit Edilemeyen", Date{}, true},
+		{"", Date{}, true},
+	}
+
+	for _, tt := range tests {
+		got, problem := ParseDate(tt.raw)
+		if got != tt.want {
+			t.Errorf("ParseDate(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+		if (problem != "") != tt.wantProblem {
+			t.Errorf("ParseDate(%q) problem = %q, want problem: %v", tt.raw, problem, tt.wantProblem)
+		}
+	}
+}
+
+func TestDateRegexpsRejectLongParts(t *testing.T) {
+	for _, raw := range []string{"123.01.2020", "01/123/2020", "1234/01/2020"} {
+		if fullDateRe.MatchString(raw) {
+			t.Errorf("fullDateRe matches %q", raw)
+		}
+	}
+	if monthDateRe.MatchString("123/2020") {
+		t.Errorf("monthDateRe matches %q", "123/2020")
+	}
+}
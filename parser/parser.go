@@ -0,0 +1,279 @@
+// Package parser extracts incident details from anitsayac.com detail pages.
+//
+// Parsing is kept free of any network access so that every format variant
+// the site has shipped can be exercised from recorded pages in testdata.
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Detail holds the fields read from a single details.aspx?id= page.
+type Detail struct {
+	Name       string   `json:"name"`
+	Age        string   `json:"age"`
+	Location   string   `json:"location"`
+	Date       string   `json:"date"`
+	DateISO    string   `json:"date_iso"`
+	Year       int      `json:"year"`
+	Month      int      `json:"month"`
+	Reason     string   `json:"reason"`
+	By         string   `json:"by"`
+	Protection string   `json:"protection"`
+	Method     string   `json:"method"`
+	Status     string   `json:"status"`
+	Source     []string `json:"source"`
+	Image      string   `json:"image"`
+
+	// Extra holds the values of labels the parser doesn't know, keyed by
+	// label, so that fields added to the site aren't lost.
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// Warning describes a recoverable problem found while parsing a page.
+type Warning struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Field, w.Message)
+}
+
+// ErrNotDetailPage is returned when a page carries none of the known labels.
+var ErrNotDetailPage = errors.New("parser: no incident labels found on page")
+
+var (
+	nameRe       = regexp.MustCompile(`(?i)<b>Ad Soyad:\s*</b>\s*(.+?)<br>`)
+	ageRe        = regexp.MustCompile(`(?i)<b>Maktülün yaşı:\s*</b>\s*(.+?)<br>`)
+	locationRe   = regexp.MustCompile(`(?i)<b>İl/ilçe:\s*</b>\s*(.+?)<br>`)
+	dateRe       = regexp.MustCompile(`(?i)<b>Tarih:\s*</b>\s*(.+?)<br>`)
+	reasonRe     = regexp.MustCompile(`(?i)<b>Neden öldürüldü:\s*</b>\s*(.*?)(?:<br><b>|<br>|$)`)
+	byRe         = regexp.MustCompile(`(?i)<b>Kim tarafından öldürüldü:\s*</b>\s*(.*?)(?:<br><b>|<br>|$)`)
+	protectionRe = regexp.MustCompile(`(?i)<b>Korunma talebi:\s*</b>\s*(.*?)(?:<br><b>|<br>|$)`)
+	methodRe     = regexp.MustCompile(`(?i)<b>Öldürülme şekli:\s*</b>\s*(.*?)(?:<br><b>|<br>|$)`)
+	statusRe     = regexp.MustCompile(`(?i)<b>Failin durumu:\s*</b>\s*(.+?)(?:<br>|$)`)
+	labelRe      = regexp.MustCompile(`<b>\s*([^<:]+?)\s*:\s*</b>\s*(.*?)(?:<br>|$)`)
+
+	tagRe          = regexp.MustCompile(`<[^>]*>`)
+	methodPrefixRe = regexp.MustCompile(`(?i).*?Öldürülme şekli:\s*`)
+	statusPrefixRe = regexp.MustCompile(`(?i).*?Failin durumu:\s*`)
+	kaynakPrefixRe = regexp.MustCompile(`(?i).*?Kaynak:\s*`)
+)
+
+// KnownLabels are the labels of the detail page the parser reads.
+var KnownLabels = []string{
+	"Ad Soyad",
+	"Maktülün yaşı",
+	"İl/ilçe",
+	"Tarih",
+	"Neden öldürüldü",
+	"Kim tarafından öldürüldü",
+	"Korunma talebi",
+	"Öldürülme şekli",
+	"Failin durumu",
+	"Kaynak",
+}
+
+// isKnownLabel reports whether label is one of KnownLabels, ignoring case.
+func isKnownLabel(label string) bool {
+	for _, known := range KnownLabels {
+		if strings.ToLower(label) == strings.ToLower(known) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultImageHost is where the site serves incident photos from.
+const DefaultImageHost = "i.anitsayac.com"
+
+// Parser extracts details from pages using a set of corrections.
+type Parser struct {
+	corrections *Corrections
+
+	// ImageBase, when set, replaces the scheme and host of photos served
+	// from DefaultImageHost, e.g. "http://127.0.0.1:8080" for a mirror.
+	ImageBase string
+}
+
+// New returns a Parser applying the given corrections.
+func New(corrections *Corrections) *Parser {
+	return &Parser{corrections: corrections}
+}
+
+var defaultParser = New(DefaultCorrections())
+
+// ParseDetail parses a detail page using the embedded corrections.
+func ParseDetail(body []byte, pageURL string) (Detail, []Warning, error) {
+	return defaultParser.ParseDetail(body, pageURL)
+}
+
+// ParseDetail extracts a Detail from the raw HTML of a detail page. pageURL
+// is the address the page was fetched from and is used to resolve relative
+// image paths. Fields whose label is missing are left empty; problems that
+// do not prevent parsing are reported as warnings.
+func (p *Parser) ParseDetail(body []byte, pageURL string) (Detail, []Warning, error) {
+	detail := Detail{}
+	var warnings []Warning
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return detail, nil, fmt.Errorf("parser: invalid page url %q: %w", pageURL, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return detail, nil, fmt.Errorf("parser: %w", err)
+	}
+
+	html := string(body)
+	found := false
+
+	if m := nameRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		detail.Name = strings.TrimSpace(m[1])
+	} else {
+		warnings = append(warnings, Warning{Field: "name", Message: "label not found"})
+	}
+
+	if m := ageRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		age := strings.TrimSpace(m[1])
+		detail.Age = p.corrections.Correct("age", age)
+	}
+
+	if m := locationRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		detail.Location = p.corrections.Correct("location", strings.TrimSpace(m[1]))
+	}
+
+	if m := dateRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		detail.Date = strings.TrimSpace(m[1])
+		date, problem := ParseDate(detail.Date)
+		detail.DateISO, detail.Year, detail.Month = date.ISO, date.Year, date.Month
+		if problem != "" {
+			warnings = append(warnings, Warning{Field: "date", Message: problem})
+		}
+	} else {
+		warnings = append(warnings, Warning{Field: "date", Message: "label not found"})
+	}
+
+	if m := reasonRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		reason := stripTags(m[1])
+		reason = p.corrections.Correct("reason", reason)
+		detail.Reason = strings.TrimSpace(reason)
+	}
+
+	if m := byRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		by := stripTags(m[1])
+		detail.By = p.corrections.Correct("by", by)
+	}
+
+	if m := protectionRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		protection := tagRe.ReplaceAllString(strings.TrimSpace(m[1]), "")
+		// Remove any content that looks like it belongs to another field
+		protection = strings.TrimSpace(methodPrefixRe.ReplaceAllString(protection, ""))
+		detail.Protection = p.corrections.Correct("protection", protection)
+	}
+
+	if m := methodRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		method := tagRe.ReplaceAllString(strings.TrimSpace(m[1]), "")
+		// Remove any content that looks like it belongs to another field
+		method = statusPrefixRe.ReplaceAllString(method, "")
+		method = strings.TrimSpace(kaynakPrefixRe.ReplaceAllString(method, ""))
+		detail.Method = p.corrections.Correct("method", method)
+	}
+
+	if m := statusRe.FindStringSubmatch(html); len(m) > 1 {
+		found = true
+		status := tagRe.ReplaceAllString(m[1], "")
+		// Remove "Kaynak:" prefix and any URLs that might be left
+		status = strings.TrimSpace(kaynakPrefixRe.ReplaceAllString(status, ""))
+		if strings.Contains(status, "http") {
+			warnings = append(warnings, Warning{Field: "status", Message: fmt.Sprintf("discarded value containing a url: %q", status)})
+			status = ""
+		}
+		detail.Status = p.corrections.Correct("status", status)
+	}
+
+	if !found {
+		return detail, warnings, ErrNotDetailPage
+	}
+
+	// Labels the site has added since the parser was last updated
+	for _, m := range labelRe.FindAllStringSubmatch(html, -1) {
+		label := strings.TrimSpace(m[1])
+		if isKnownLabel(label) {
+			continue
+		}
+		if detail.Extra == nil {
+			detail.Extra = map[string]string{}
+		}
+		detail.Extra[label] = stripTags(m[2])
+		warnings = append(warnings, Warning{Field: "schema", Message: fmt.Sprintf("unknown label %q", label)})
+	}
+
+	doc.Find("body a").Each(func(_ int, s *goquery.Selection) {
+		if href, ok := s.Attr("href"); ok {
+			detail.Source = append(detail.Source, strings.TrimSpace(href))
+		}
+	})
+
+	if src, ok := doc.Find("body img").Attr("src"); ok {
+		detail.Image = p.rebaseImage(resolveImage(base, strings.TrimSpace(src)))
+	}
+
+	return detail, warnings, nil
+}
+
+// rebaseImage moves a photo on DefaultImageHost to ImageBase.
+func (p *Parser) rebaseImage(image string) string {
+	if p.ImageBase == "" {
+		return image
+	}
+	u, err := url.Parse(image)
+	if err != nil || u.Host != DefaultImageHost {
+		return image
+	}
+	return strings.TrimSuffix(p.ImageBase, "/") + u.RequestURI()
+}
+
+// resolveImage turns the src attribute of the incident photo into an
+// absolute URL relative to the page it was found on.
+func resolveImage(base *url.URL, src string) string {
+	scheme := base.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	switch {
+	case src == "":
+		return ""
+	case strings.HasPrefix(src, "//"):
+		// Protocol-relative URL (//domain.com/path)
+		return scheme + ":" + src
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		return src
+	default:
+		// Relative path, prepend the page's origin
+		return scheme + "://" + base.Host + "/" + strings.TrimPrefix(src, "/")
+	}
+}
+
+// stripTags removes any HTML tags captured along with a field value.
+func stripTags(s string) string {
+	return strings.TrimSpace(tagRe.ReplaceAllString(strings.TrimSpace(s), ""))
+}
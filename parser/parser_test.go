@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+type golden struct {
+	Detail   Detail    `json:"detail"`
+	Warnings []Warning `json:"warnings"`
+}
+
+// TestParseDetailGolden parses every recorded page in testdata and compares
+// the result with its .golden.json counterpart.
+func TestParseDetailGolden(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("no fixtures found in testdata")
+	}
+
+	for _, page := range pages {
+		id := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(id, func(t *testing.T) {
+			body, err := os.ReadFile(page)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			detail, warnings, err := ParseDetail(body, "https://anitsayac.com/details.aspx?id="+id)
+			if err != nil {
+				t.Fatalf("ParseDetail: %v", err)
+			}
+
+			got, err := json.MarshalIndent(golden{Detail: detail, Warnings: warnings}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			goldenFile := filepath.Join("testdata", id+".golden.json")
+			if *update {
+				if err := os.WriteFile(goldenFile, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("%s (run with -update to create it)", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("mismatch with %s\ngot:\n%s\nwant:\n%s", goldenFile, got, want)
+			}
+		})
+	}
+}
+
+func TestParseDetailNotDetailPage(t *testing.T) {
+	_, _, err := ParseDetail([]byte("<html><body><h1>Server Error</h1></body></html>"), "https://anitsayac.com/details.aspx?id=1")
+	if !errors.Is(err, ErrNotDetailPage) {
+		t.Fatalf("got err %v, want ErrNotDetailPage", err)
+	}
+}
+
+func TestResolveImage(t *testing.T) {
+	tests := []struct {
+		page, src, want string
+	}{
+		{"https://anitsayac.com/details.aspx?id=1", "ii/1.jpg", "https://anitsayac.com/ii/1.jpg"},
+		{"https://anitsayac.com/details.aspx?id=1", "//i.anitsayac.com/ii/1.jpg", "https://i.anitsayac.com/ii/1.jpg"},
+		{"http://127.0.0.1:8080/details.aspx?id=1", "//i.anitsayac.com/ii/1.jpg", "http://i.anitsayac.com/ii/1.jpg"},
+		{"https://anitsayac.com/details.aspx?id=1", "http://example.com/1.jpg", "http://example.com/1.jpg"},
+		{"https://anitsayac.com/details.aspx?id=1", "", ""},
+	}
+
+	for _, tt := range tests {
+		detail, _, err := ParseDetail([]byte("<b>Ad Soyad:</b> X<br><img src='"+tt.src+"'>"), tt.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detail.Image != tt.want {
+			t.Errorf("page %s src %q: got %q, want %q", tt.page, tt.src, detail.Image, tt.want)
+		}
+	}
+}
+
+func TestImageBase(t *testing.T) {
+	p := New(DefaultCorrections())
+	p.ImageBase = "http://127.0.0.1:8080/"
+
+	tests := []struct {
+		src, want string
+	}{
+		{"//i.anitsayac.com/ii/972024.jpg", "http://127.0.0.1:8080/ii/972024.jpg"},
+		{"https://i.anitsayac.com/ii/972024.jpg", "http://127.0.0.1:8080/ii/972024.jpg"},
+		{"ii/1.jpg", "https://anitsayac.com/ii/1.jpg"},
+		{"http://example.com/1.jpg", "http://example.com/1.jpg"},
+	}
+
+	for _, tt := range tests {
+		detail, _, err := p.ParseDetail([]byte("<b>Ad Soyad:</b> X<br><img src='"+tt.src+"'>"), "https://anitsayac.com/details.aspx?id=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detail.Image != tt.want {
+			t.Errorf("src %q: got %q, want %q", tt.src, detail.Image, tt.want)
+		}
+	}
+}
+
+func TestParseDetailKeepsReasonCommas(t *testing.T) {
+	detail, _, err := ParseDetail([]byte("<b>Ad Soyad:</b> X<br><b>Neden öldürüldü:</b> Kıskançlık, Tartışma<br>"), "https://anitsayac.com/details.aspx?id=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Kıskançlık, Tartışma"; detail.Reason != want {
+		t.Errorf("reason = %q, want %q", detail.Reason, want)
+	}
+}
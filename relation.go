@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"AnitSayac_Scrapper/crawler/parser"
+	"AnitSayac_Scrapper/crawler/taxonomy"
+)
+
+var relations = taxonomy.Default()
+
+// relationFormats are the formats the coverage report can be printed in.
+var relationFormats = map[string]func(io.Writer, relationCoverage) error{
+	"json":     func(w io.Writer, c relationCoverage) error { return writeJSON(w, c) },
+	"markdown": writeRelationMarkdown,
+}
+
+// relationCoverage tells how much of the By values the taxonomy maps.
+// Shares are of the incidents having a By value.
+type relationCoverage struct {
+	Total      int          `json:"total"`
+	Mapped     int          `json:"mapped"`
+	Share      float64      `json:"share"`
+	Categories []statsCount `json:"categories"`
+	Relations  []statsCount `json:"relations"`
+	// Unmapped lists the distinct values missing from the taxonomy
+	Unmapped []statsCount `json:"unmapped"`
+}
+
+// classifyRelation fills ByRelation and ByCategory from By and records a
+// warning when a non-empty value isn't in the taxonomy.
+func classifyRelation(incident Incident) Incident {
+	m := relations.Classify(incident.By)
+	incident.ByRelation = m.Relation
+	incident.ByCategory = m.Category
+
+	incident.Warnings = withoutField(incident.Warnings, "by_category")
+	if incident.By != "" && !m.Mapped() {
+		incident.Warnings = append(incident.Warnings, parser.Warning{
+			Field:   "by_category",
+			Message: fmt.Sprintf("unmapped relation %q", incident.By),
+		})
+	}
+	return incident
+}
+
+// computeRelationCoverage counts the incidents per category, relation and
+// unmapped value, most frequent first. Categories are listed in the
+// taxonomy's order, empty ones included.
+func computeRelationCoverage(incidents []Incident) relationCoverage {
+	categories, byRelation, unmapped := map[string]int{}, map[string]int{}, map[string]int{}
+	var c relationCoverage
+	for _, incident := range incidents {
+		if incident.By == "" {
+			continue
+		}
+		c.Total++
+		if incident.ByCategory == "" {
+			unmapped[incident.By]++
+			continue
+		}
+		c.Mapped++
+		categories[incident.ByCategory]++
+		byRelation[incident.ByRelation]++
+	}
+	c.Share = share(c.Mapped, c.Total)
+
+	c.Categories = []statsCount{}
+	for _, category := range taxonomy.Categories {
+		c.Categories = append(c.Categories, statsCount{Value: category, Count: categories[category], Share: share(categories[category], c.Total)})
+	}
+	c.Relations = countsByFrequency(byRelation, c.Total)
+	c.Unmapped = countsByFrequency(unmapped, c.Total)
+	return c
+}
+
+// countsByFrequency lists counted values by decreasing count, then value.
+func countsByFrequency(counts map[string]int, total int) []statsCount {
+	list := make([]statsCount, 0, len(counts))
+	for value, count := range counts {
+		list = append(list, statsCount{Value: value, Count: count, Share: share(count, total)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Value < list[j].Value
+	})
+	return list
+}
+
+// reportUnmappedRelations logs every distinct relation that couldn't be
+// classified, most frequent first.
+func reportUnmappedRelations(incidents []Incident) {
+	unmapped := computeRelationCoverage(incidents).Unmapped
+	if len(unmapped) == 0 {
+		return
+	}
+	log.Printf("%d unmapped relation(s):", len(unmapped))
+	for _, c := range unmapped {
+		log.Printf("  %q (%d)", c.Value, c.Count)
+	}
+}
+
+// writeRelationMarkdown writes the coverage report as Markdown tables.
+func writeRelationMarkdown(w io.Writer, c relationCoverage) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Perpetrator relations\n\n%d of the %d incidents naming a perpetrator are mapped to the taxonomy (%s%%), %d distinct value(s) are not.\n",
+		c.Mapped, c.Total, formatPercent(c.Share), len(c.Unmapped))
+
+	for _, table := range []struct {
+		title  string
+		counts []statsCount
+	}{
+		{"Category", c.Categories},
+		{"Relation", c.Relations},
+		{"Unmapped", c.Unmapped},
+	} {
+		if len(table.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", table.title)
+		fmt.Fprintf(&b, "| %s | Count | Share |\n", table.title)
+		fmt.Fprintln(&b, "| --- | ---: | ---: |")
+		for _, count := range table.counts {
+			fmt.Fprintf(&b, "| %s | %d | %s%% |\n", markdownEscape(count.Value), count.Count, formatPercent(count.Share))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// runRelations prints how the perpetrator relations of a dataset map to
+// the taxonomy.
+func runRelations(args []string) error {
+	fs := flag.NewFlagSet("relations", flag.ExitOnError)
+	in := fs.String("in", jsonFileName, "JSON dataset to check")
+	format := fs.String("format", "markdown", "output format: "+strings.Join(relationFormatNames(), ", "))
+	out := fs.String("out", "", "output file (default stdout)")
+	minShare := fs.Float64("min-share", 0, "fail when less than this share of the values, from 0 to 1, is mapped")
+	fs.Parse(args)
+
+	write, ok := relationFormats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected one of: %s", *format, strings.Join(relationFormatNames(), ", "))
+	}
+
+	incidents, err := loadIncidents(*in)
+	if err != nil {
+		return err
+	}
+	for i := range incidents {
+		incidents[i] = upgradeIncident(incidents[i])
+	}
+
+	c := computeRelationCoverage(incidents)
+	if *out == "" {
+		err = write(os.Stdout, c)
+	} else {
+		err = writeFile(*out, func(w io.Writer) error {
+			return write(w, c)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if c.Share < *minShare {
+		return fmt.Errorf("%s%% of the relations are mapped, less than -min-share %s%%", formatPercent(c.Share), formatPercent(*minShare))
+	}
+	return nil
+}
+
+func relationFormatNames() []string {
+	names := make([]string, 0, len(relationFormats))
+	for name := range relationFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClassifyRelation(t *testing.T) {
+	incident := classifyRelation(Incident{By: "Eskİ Kocası"})
+	if incident.ByRelation != "Eski Kocası" || incident.ByCategory != "former_partner" || len(incident.Warnings) != 0 {
+		t.Errorf("classifyRelation = %q, %q, %v", incident.ByRelation, incident.ByCategory, incident.Warnings)
+	}
+
+	incident = classifyRelation(Incident{By: "Reddedilme"})
+	if incident.ByCategory != "" || len(incident.Warnings) != 1 || incident.Warnings[0].Field != "by_category" {
+		t.Errorf("unmapped relation = %q, %v", incident.ByCategory, incident.Warnings)
+	}
+	// Classifying again doesn't repeat the warning
+	if again := classifyRelation(incident); len(again.Warnings) != 1 {
+		t.Errorf("warnings after a second pass = %v", again.Warnings)
+	}
+}
+
+func TestRelationCoverage(t *testing.T) {
+	var incidents []Incident
+	for _, by := range []string{"Kocası", "Koccası", "Eski Sevgilisi", "Tespit Edilemeyen", "Reddedilme", "Reddedilme", "İntihar", ""} {
+		incidents = append(incidents, classifyRelation(Incident{By: by}))
+	}
+	c := computeRelationCoverage(incidents)
+
+	if c.Total != 7 || c.Mapped != 4 {
+		t.Errorf("mapped %d of %d, want 4 of 7", c.Mapped, c.Total)
+	}
+	if want := []statsCount{{"Reddedilme", 2, 2.0 / 7}, {"İntihar", 1, 1.0 / 7}}; !reflect.DeepEqual(c.Unmapped, want) {
+		t.Errorf("unmapped = %+v, want %+v", c.Unmapped, want)
+	}
+	if want := (statsCount{"Kocası", 2, 2.0 / 7}); c.Relations[0] != want {
+		t.Errorf("top relation = %+v, want %+v", c.Relations[0], want)
+	}
+	// Every category is listed, in order
+	if len(c.Categories) != 6 || c.Categories[0].Value != "current_partner" || c.Categories[2] != (statsCount{"family", 0, 0}) {
+		t.Errorf("categories = %+v", c.Categories)
+	}
+
+	var b strings.Builder
+	if err := writeRelationMarkdown(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"4 of the 7 incidents naming a perpetrator are mapped to the taxonomy (57.1%), 2 distinct value(s) are not.",
+		"| current\\_partner | 2 | 28.6% |",
+		"| Reddedilme | 2 | 28.6% |",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("markdown is missing %q:\n%s", line, b.String())
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.html
+var reportTemplates embed.FS
+
+// reportPages are the pages of the generated site, by file name.
+var reportPages = []string{"index.html", "incidents.html"}
+
+// reportData is what the report templates are executed with.
+type reportData struct {
+	Generated  string
+	LastDate   string
+	Stats      datasetStats
+	YearChart  template.HTML
+	Charts     []reportChart
+	Provinces  []provinceRow
+	Incidents  []Incident
+	Unresolved int
+}
+
+// reportChart is a titled bar chart.
+type reportChart struct {
+	Title string
+	SVG   template.HTML
+}
+
+// provinceRow is a line of the province table, shaded by its count.
+type provinceRow struct {
+	Code  int
+	Name  string
+	Count int
+	Share float64
+	// Shade is the opacity of the row's colour, from 0 to 1
+	Shade float64
+}
+
+// chartValue is a labelled value of a chart.
+type chartValue struct {
+	Label string
+	Value int
+}
+
+// Chart colours, shared with the stylesheet in templates/layout.html.
+const (
+	chartColor = "#6a1b9a"
+	gridColor  = "#ddd"
+	textColor  = "#333"
+)
+
+// buildReport computes what the report shows. Bar charts list the top
+// values of their field and group the rest.
+func buildReport(incidents []Incident, top int, generated time.Time) reportData {
+	s := computeStats(incidents, top, 0)
+	data := reportData{
+		Generated: generated.UTC().Format("2006-01-02 15:04 UTC"),
+		Stats:     s,
+		Incidents: append([]Incident(nil), incidents...),
+	}
+
+	for _, b := range s.Breakdowns {
+		var values []chartValue
+		for _, c := range b.Counts {
+			values = append(values, chartValue{Label: c.Value, Value: c.Count})
+		}
+		switch b.Name {
+		case "year":
+			data.YearChart = lineChartSVG(yearSeries(values))
+		case "method", "by", "by_category":
+			data.Charts = append(data.Charts, reportChart{Title: b.Title, SVG: barChartSVG(values)})
+		}
+	}
+
+	counts := map[string]int{}
+	for _, incident := range incidents {
+		if incident.Province == "" {
+			data.Unresolved++
+		}
+		counts[incident.Province]++
+	}
+	most := 0
+	for _, p := range locations.Provinces() {
+		most = max(most, counts[p.Name])
+	}
+	for _, p := range locations.Provinces() {
+		row := provinceRow{Code: p.Code, Name: p.Name, Count: counts[p.Name], Share: share(counts[p.Name], len(incidents))}
+		if most > 0 {
+			row.Shade = math.Round(float64(row.Count)/float64(most)*100) / 100
+		}
+		data.Provinces = append(data.Provinces, row)
+	}
+	sort.SliceStable(data.Provinces, func(i, j int) bool {
+		return data.Provinces[i].Count > data.Provinces[j].Count
+	})
+
+	// Most recent first, as the site lists them
+	sort.SliceStable(data.Incidents, func(i, j int) bool {
+		a, b := data.Incidents[i], data.Incidents[j]
+		if a.DateISO != b.DateISO {
+			return a.DateISO > b.DateISO
+		}
+		return a.Id > b.Id
+	})
+	if len(data.Incidents) > 0 {
+		data.LastDate = data.Incidents[0].DateISO
+	}
+	return data
+}
+
+// yearSeries returns the count of every year from the first to the last one
+// in values, with 0 for the years missing in between, since the trend spaces
+// its points evenly. Incidents of an unknown year are left out.
+func yearSeries(values []chartValue) []chartValue {
+	counts := map[int]int{}
+	first, last := 0, 0
+	for _, v := range values {
+		year, err := strconv.Atoi(v.Label)
+		if err != nil {
+			continue
+		}
+		counts[year] = v.Value
+		if first == 0 || year < first {
+			first = year
+		}
+		last = max(last, year)
+	}
+	if first == 0 {
+		return nil
+	}
+
+	var years []chartValue
+	for year := first; year <= last; year++ {
+		years = append(years, chartValue{Label: strconv.Itoa(year), Value: counts[year]})
+	}
+	return years
+}
+
+// lineChartSVG draws values as a line with a point per value, labelling
+// every few values on the horizontal axis.
+func lineChartSVG(values []chartValue) template.HTML {
+	const (
+		width, height          = 720, 280
+		left, right, top, foot = 48, 16, 16, 32
+	)
+	if len(values) == 0 {
+		return ""
+	}
+
+	most := 0
+	for _, v := range values {
+		most = max(most, v.Value)
+	}
+	step := niceStep(most)
+	yMax := step * int(math.Ceil(float64(most)/float64(step)))
+	if yMax == 0 {
+		yMax = 1
+	}
+
+	x := func(i int) float64 {
+		if len(values) == 1 {
+			return float64(left+width-right) / 2
+		}
+		return left + float64(i)*float64(width-left-right)/float64(len(values)-1)
+	}
+	y := func(v int) float64 {
+		return top + float64(height-top-foot)*(1-float64(v)/float64(yMax))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" role="img" aria-label="Incidents per year">`, width, height)
+	for v := 0; v <= yMax; v += step {
+		fmt.Fprintf(&b, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" stroke="%s"/>`, left, width-right, y(v), y(v), gridColor)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle" font-size="11" fill="%s">%d</text>`, left-6, y(v), textColor, v)
+	}
+
+	every := int(math.Ceil(float64(len(values)) / 12))
+	var points []string
+	for i, v := range values {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(v.Value)))
+		if i%every == 0 || i == len(values)-1 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" font-size="11" fill="%s">%s</text>`, x(i), height-foot+18, textColor, template.HTMLEscapeString(v.Label))
+		}
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), chartColor)
+	for i, v := range values {
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3.5" fill="%s"><title>%s: %d</title></circle>`, x(i), y(v.Value), chartColor, template.HTMLEscapeString(v.Label), v.Value)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// barChartSVG draws values as horizontal bars, one per line.
+func barChartSVG(values []chartValue) template.HTML {
+	const (
+		width, label, valueWidth = 720, 200, 56
+		bar, gap                 = 18, 6
+	)
+	if len(values) == 0 {
+		return ""
+	}
+
+	most := 0
+	for _, v := range values {
+		most = max(most, v.Value)
+	}
+	height := len(values)*(bar+gap) + gap
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" role="img">`, width, height)
+	for i, v := range values {
+		y := gap + i*(bar+gap)
+		length := 0.0
+		if most > 0 {
+			length = float64(width-label-valueWidth) * float64(v.Value) / float64(most)
+		}
+		text := template.HTMLEscapeString(v.Label)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" dominant-baseline="middle" font-size="12" fill="%s">%s</text>`, label-8, y+bar/2, textColor, text)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %d</title></rect>`, label, y, length, bar, chartColor, text, v.Value)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" dominant-baseline="middle" font-size="12" fill="%s">%d</text>`, float64(label)+length+6, y+bar/2, textColor, v.Value)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// niceStep returns a round grid step giving about four lines up to most.
+func niceStep(most int) int {
+	if most <= 4 {
+		return 1
+	}
+	raw := float64(most) / 4
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if step := m * magnitude; step >= raw {
+			return int(step)
+		}
+	}
+	return int(10 * magnitude)
+}
+
+// writeReport renders every page of the site into dir.
+func writeReport(dir string, data reportData) error {
+	templates, err := template.New("").Funcs(template.FuncMap{
+		"percent": formatPercent,
+		"add":     func(a, b int) int { return a + b },
+		"shade": func(f float64) template.CSS {
+			return template.CSS(fmt.Sprintf("background: rgba(106, 27, 154, %.2f)", f))
+		},
+		"name": func(incident Incident) string {
+			if incident.FullName != "" {
+				return incident.FullName
+			}
+			return incident.Name
+		},
+	}).ParseFS(reportTemplates, "templates/*.html")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, page := range reportPages {
+		if err := writeFileAtomic(filepath.Join(dir, page), func(w io.Writer) error {
+			return templates.ExecuteTemplate(w, page, data)
+		}); err != nil {
+			return fmt.Errorf("%s: %w", page, err)
+		}
+	}
+	return nil
+}
+
+// runReport generates the static report site.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	in := fs.String("in", jsonFileName, "JSON dataset to report on")
+	out := fs.String("out", "site", "directory the site is written to")
+	top := fs.Int("top", 12, "bars per chart, the rest grouped as (other)")
+	fs.Parse(args)
+
+	incidents, err := loadIncidents(*in)
+	if err != nil {
+		return err
+	}
+	for i := range incidents {
+		incidents[i] = upgradeIncident(incidents[i])
+	}
+	incidents = withoutRemoved(incidents)
+
+	if err := writeReport(*out, buildReport(incidents, *top, time.Now())); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the report on %d incidents to %s\n", len(incidents), *out)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReport(t *testing.T) {
+	incidents := []Incident{
+		{Id: 1, Name: "A", DateISO: "2023-03-02", Year: 2023, Province: "İzmir", Method: "Ateşli Silah", By: "Kocası", Protection: "Yok"},
+		{Id: 2, Name: "B <b>", DateISO: "2024-05-10", Year: 2024, Province: "İzmir", Method: "Kesici Alet", By: "Oğlu", Protection: "Var"},
+		{Id: 3, Name: "C", FullName: "Ceren C.", DateISO: "2024-01-01", Year: 2024, Province: "Ankara", Method: "Ateşli Silah", By: "Kocası"},
+	}
+	dir := filepath.Join(t.TempDir(), "site")
+	if err := writeReport(dir, buildReport(incidents, 10, time.Date(2024, 10, 18, 0, 0, 0, 0, time.UTC))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing may be loaded from elsewhere
+	external := regexp.MustCompile(`<script[^>]+src=|<link[^>]+href=|@import|<img|<iframe`)
+	pages := map[string]string{}
+	for _, page := range reportPages {
+		content, err := os.ReadFile(filepath.Join(dir, page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[page] = string(content)
+		if loc := external.FindStringIndex(pages[page]); loc != nil {
+			t.Errorf("%s loads an external resource: %s", page, pages[page][loc[0]:loc[1]])
+		}
+	}
+
+	for page, wants := range map[string][]string{
+		"index.html": {
+			"Of the 2 whose protection status is known, <strong>1</strong> had asked for protection (50.0%)",
+			`aria-label="Incidents per year"`,
+			`<title>2024: 2</title>`,
+			`<title>Ateşli Silah: 2</title>`,
+			`<td>İzmir</td><td class="number dark" style="background: rgba(106, 27, 154, 1.00)">2</td>`,
+			"Generated on 2024-10-18 00:00 UTC, latest incident on 2024-05-10.",
+		},
+		"incidents.html": {
+			"B &lt;b&gt;",
+			">Ceren C.</a>",
+			`<span id="shown">3</span>`,
+		},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(pages[page], want) {
+				t.Errorf("%s is missing %q", page, want)
+			}
+		}
+	}
+
+	// Incidents are listed most recent first
+	if i, j := strings.Index(pages["incidents.html"], "2024-05-10"), strings.Index(pages["incidents.html"], "2023-03-02"); i < 0 || j < i {
+		t.Errorf("incidents are not ordered by date")
+	}
+}
+
+func TestNiceStep(t *testing.T) {
+	for most, want := range map[int]int{0: 1, 3: 1, 10: 5, 457: 200, 700: 200, 2643: 1000} {
+		if got := niceStep(most); got != want {
+			t.Errorf("niceStep(%d) = %d, want %d", most, got, want)
+		}
+	}
+}
+
+func TestYearSeries(t *testing.T) {
+	values := []chartValue{
+		{Label: "2024", Value: 3},
+		{Label: statsUnknown, Value: 5},
+		{Label: "2021", Value: 2},
+		{Label: "2023", Value: 1},
+	}
+	want := []chartValue{
+		{Label: "2021", Value: 2},
+		{Label: "2022", Value: 0},
+		{Label: "2023", Value: 1},
+		{Label: "2024", Value: 3},
+	}
+	if got := yearSeries(values); !reflect.DeepEqual(got, want) {
+		t.Errorf("yearSeries = %+v, want %+v", got, want)
+	}
+	if got := yearSeries([]chartValue{{Label: statsUnknown, Value: 1}}); got != nil {
+		t.Errorf("yearSeries without a known year = %+v, want nil", got)
+	}
+}
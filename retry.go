@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// retryOptions controls how failed requests are retried.
+type retryOptions struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// failure records an incident whose detail page could not be fetched or
+// parsed after all attempts.
+type failure struct {
+	Id       int    `json:"id"`
+	Url      string `json:"url"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// backoff returns the delay before the given retry attempt: the base delay
+// doubled per attempt, capped at MaxDelay, with random jitter on the upper
+// half so parallel workers don't retry in lockstep.
+func (o retryOptions) backoff(attempt int) time.Duration {
+	delay := o.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > o.MaxDelay {
+		delay = o.MaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryable reports whether a failed response is worth another attempt.
+// Transport errors carry no status code.
+func retryable(r *colly.Response) bool {
+	return r.StatusCode == 0 || r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= 500
+}
+
+// attempts returns how many times the request has been sent so far.
+func attempts(r *colly.Request) int {
+	if n, ok := r.Ctx.GetAny("attempts").(int); ok {
+		return n
+	}
+	return 1
+}
+
+// dropCached removes the cached response of url from cacheDir, where colly
+// keeps it under the SHA-1 of the url.
+func dropCached(cacheDir, url string) {
+	if cacheDir == "" {
+		return
+	}
+	sum := sha1.Sum([]byte(url))
+	hash := hex.EncodeToString(sum[:])
+	if err := os.Remove(filepath.Join(cacheDir, hash[:2], hash)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Cannot remove the cached response of %s: %s", url, err)
+	}
+}
+
+// retryOnError retries transient errors on c with exponential backoff and
+// calls giveUp once a request has failed for good.
+func retryOnError(c *colly.Collector, opts retryOptions, giveUp func(r *colly.Response, err error)) {
+	c.OnError(func(r *colly.Response, err error) {
+		// colly caches every response below 500, so a 429 would otherwise
+		// be read back on the retry and on every later run
+		if r.StatusCode == http.StatusTooManyRequests {
+			dropCached(c.CacheDir, r.Request.URL.String())
+		}
+
+		attempt := attempts(r.Request)
+		if attempt < opts.MaxAttempts && retryable(r) {
+			delay := opts.backoff(attempt)
+			log.Printf("Attempt %d for %s failed: %s, retrying in %s", attempt, r.Request.URL, err, delay)
+			time.Sleep(delay)
+
+			r.Ctx.Put("attempts", attempt+1)
+			retryErr := r.Request.Retry()
+			if retryErr == nil {
+				return
+			}
+			err = retryErr
+		}
+		giveUp(r, err)
+	})
+}
+
+// writeFailures writes the failure report, replacing any previous one.
+func writeFailures(path string, failures []failure) error {
+	return writeFile(path, func(w io.Writer) error {
+		return writeJSON(w, failures)
+	})
+}
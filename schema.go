@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// Ways of handling labels the parser doesn't know.
+const (
+	unknownLabelsWarn = "warn"
+	unknownLabelsFail = "fail"
+)
+
+// unknownLabels counts the incidents carrying each label the parser
+// doesn't know.
+func unknownLabels(incidents []Incident) map[string]int {
+	counts := map[string]int{}
+	for _, incident := range incidents {
+		for label := range incident.Extra {
+			counts[label]++
+		}
+	}
+	return counts
+}
+
+// checkUnknownLabels reports labels that appeared on the site since the
+// parser was last updated, and fails in unknownLabelsFail mode. On GitHub
+// Actions the labels are also raised as annotations.
+func checkUnknownLabels(incidents []Incident, mode string) error {
+	counts := unknownLabels(incidents)
+	if len(counts) == 0 {
+		return nil
+	}
+
+	labels := make([]string, 0, len(counts))
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	annotation := "warning"
+	if mode == unknownLabelsFail {
+		annotation = "error"
+	}
+	log.Printf("%d unknown label(s) on detail pages, kept in extra:", len(labels))
+	for _, label := range labels {
+		log.Printf("  %q (%d incident(s))", label, counts[label])
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			fmt.Printf("::%s title=Unknown label::%q found on %d detail page(s)\n", annotation, label, counts[label])
+		}
+	}
+
+	if mode == unknownLabelsFail {
+		return fmt.Errorf("%d unknown label(s) on detail pages, update the parser or run with -unknown-labels %s", len(labels), unknownLabelsWarn)
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"AnitSayac_Scrapper/crawler/gazetteer"
+)
+
+const (
+	defaultPerPage = 50
+	maxPerPage     = 1000
+	// reloadCheckInterval is how often at most the server checks whether
+	// the dataset file changed.
+	reloadCheckInterval = time.Second
+)
+
+// servedDataset is a loaded data.json together with the validators of the
+// file it was read from.
+type servedDataset struct {
+	incidents []Incident
+	byId      map[int]Incident
+	etag      string
+	modTime   time.Time
+}
+
+// loadServedDataset reads and upgrades the dataset at path.
+func loadServedDataset(path string) (*servedDataset, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var incidents []Incident
+	if err := json.Unmarshal(content, &incidents); err != nil {
+		return nil, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	for i := range incidents {
+		incidents[i] = upgradeIncident(incidents[i])
+	}
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].Id < incidents[j].Id
+	})
+
+	sum := sha256.Sum256(content)
+	return &servedDataset{
+		incidents: incidents,
+		byId:      incidentsById(incidents),
+		etag:      `"` + hex.EncodeToString(sum[:8]) + `"`,
+		modTime:   info.ModTime(),
+	}, nil
+}
+
+// incidentServer answers the query API from a dataset file, reloading it
+// whenever the file changes so a running server follows the crawls.
+type incidentServer struct {
+	path       string
+	checkEvery time.Duration
+
+	mu      sync.Mutex
+	dataset *servedDataset
+	checked time.Time
+}
+
+func newIncidentServer(path string) (*incidentServer, error) {
+	dataset, err := loadServedDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	return &incidentServer{path: path, checkEvery: reloadCheckInterval, dataset: dataset, checked: time.Now()}, nil
+}
+
+// current returns the dataset, reloading the file if it was modified. The
+// file is checked at most once per checkEvery, and outside the lock so that
+// other requests keep being served meanwhile. A file that fails to load
+// keeps the previous dataset in service.
+func (s *incidentServer) current() *servedDataset {
+	s.mu.Lock()
+	served := s.dataset
+	due := time.Since(s.checked) >= s.checkEvery
+	if due {
+		s.checked = time.Now()
+	}
+	s.mu.Unlock()
+	if !due {
+		return served
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil || info.ModTime().Equal(served.modTime) {
+		return served
+	}
+	dataset, err := loadServedDataset(s.path)
+	if err != nil {
+		log.Printf("Cannot reload %s, serving the previous dataset: %s", s.path, err)
+		return served
+	}
+	log.Printf("Reloaded %s with %d incidents", s.path, len(dataset.incidents))
+
+	s.mu.Lock()
+	s.dataset = dataset
+	s.mu.Unlock()
+	return dataset
+}
+
+// Handler returns the routes of the API.
+func (s *incidentServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/incidents", s.listIncidents)
+	mux.HandleFunc("/incidents/", s.getIncident)
+	return mux
+}
+
+// incidentPage is the response of /incidents.
+type incidentPage struct {
+	SchemaVersion int        `json:"schema_version"`
+	Total         int        `json:"total"`
+	Page          int        `json:"page"`
+	PerPage       int        `json:"per_page"`
+	Pages         int        `json:"pages"`
+	Incidents     []Incident `json:"incidents"`
+}
+
+func (s *incidentServer) listIncidents(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	query, err := parseIncidentQuery(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dataset := s.current()
+	matched := query.filter(dataset.incidents)
+	query.sort(matched)
+
+	page := incidentPage{
+		SchemaVersion: schemaVersion,
+		Total:         len(matched),
+		Page:          query.Page,
+		PerPage:       query.PerPage,
+		Pages:         (len(matched) + query.PerPage - 1) / query.PerPage,
+		Incidents:     []Incident{},
+	}
+	if start := (query.Page - 1) * query.PerPage; start < len(matched) {
+		end := start + query.PerPage
+		if end > len(matched) {
+			end = len(matched)
+		}
+		page.Incidents = matched[start:end]
+	}
+	serveJSON(w, r, dataset, page)
+}
+
+func (s *incidentServer) getIncident(w http.ResponseWriter, r *http.Request) {
+	if !allowRead(w, r) {
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/incidents/"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	dataset := s.current()
+	incident, ok := dataset.byId[id]
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, fmt.Sprintf("no incident %d", id))
+		return
+	}
+	serveJSON(w, r, dataset, incident)
+}
+
+// allowRead rejects every method but GET and HEAD.
+func allowRead(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// serveJSON writes v with the dataset's ETag and Last-Modified, answering
+// conditional requests with 304 Not Modified.
+func serveJSON(w http.ResponseWriter, r *http.Request, dataset *servedDataset, v interface{}) {
+	var body bytes.Buffer
+	if err := writeJSON(&body, v); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("ETag", dataset.etag)
+	http.ServeContent(w, r, "", dataset.modTime, bytes.NewReader(body.Bytes()))
+}
+
+// apiError is the body of every error response.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	writeJSON(w, apiError{Error: message})
+}
+
+// incidentQuery holds the filters, order and page of an /incidents request.
+// Text filters match any of their values, ignoring case and Turkish
+// diacritics.
+type incidentQuery struct {
+	YearFrom, YearTo int
+	Province         []string
+	District         []string
+	Method           []string
+	By               []string
+	ByCategory       []string
+	Status           []string
+	Age              []string
+	AgeMin, AgeMax   int
+	Removed          string
+
+	Sort       string
+	Descending bool
+	Page       int
+	PerPage    int
+}
+
+// incidentSorts are the fields /incidents can be sorted by. Ties are
+// broken by id.
+var incidentSorts = map[string]func(a, b Incident) int{
+	"id":       func(a, b Incident) int { return 0 },
+	"date":     func(a, b Incident) int { return strings.Compare(a.DateISO, b.DateISO) },
+	"year":     func(a, b Incident) int { return a.Year - b.Year },
+	"age":      func(a, b Incident) int { return numericAge(a) - numericAge(b) },
+	"name":     func(a, b Incident) int { return strings.Compare(a.Name, b.Name) },
+	"province": func(a, b Incident) int { return strings.Compare(a.Province, b.Province) },
+}
+
+// parseIncidentQuery reads the query string of an /incidents request.
+func parseIncidentQuery(values url.Values) (incidentQuery, error) {
+	q := incidentQuery{Sort: "id", Removed: "exclude", Page: 1, PerPage: defaultPerPage}
+
+	var err error
+	number := func(name string, target *int) {
+		if v := values.Get(name); v != "" && err == nil {
+			if *target, err = strconv.Atoi(v); err != nil {
+				err = fmt.Errorf("invalid %s %q", name, v)
+			}
+		}
+	}
+	number("year_from", &q.YearFrom)
+	number("year_to", &q.YearTo)
+	if values.Get("year") != "" {
+		number("year", &q.YearFrom)
+		q.YearTo = q.YearFrom
+	}
+	number("age_min", &q.AgeMin)
+	number("age_max", &q.AgeMax)
+	number("page", &q.Page)
+	number("per_page", &q.PerPage)
+	if err != nil {
+		return q, err
+	}
+	if q.Page < 1 {
+		return q, fmt.Errorf("invalid page %d", q.Page)
+	}
+	if q.PerPage < 1 || q.PerPage > maxPerPage {
+		return q, fmt.Errorf("per_page must be between 1 and %d", maxPerPage)
+	}
+
+	text := func(name string) []string {
+		var normalized []string
+		for _, v := range values[name] {
+			// Repeated parameters and comma-separated values both work
+			for _, part := range strings.Split(v, ",") {
+				if part = gazetteer.Normalize(part); part != "" {
+					normalized = append(normalized, part)
+				}
+			}
+		}
+		return normalized
+	}
+	q.Province = text("province")
+	q.District = text("district")
+	q.Method = text("method")
+	q.By = text("by")
+	q.ByCategory = text("by_category")
+	q.Status = text("status")
+	q.Age = text("age")
+
+	if v := values.Get("removed"); v != "" {
+		if v != "exclude" && v != "include" && v != "only" {
+			return q, fmt.Errorf("invalid removed %q, expected exclude, include or only", v)
+		}
+		q.Removed = v
+	}
+
+	if v := values.Get("sort"); v != "" {
+		q.Sort, q.Descending = strings.TrimPrefix(v, "-"), strings.HasPrefix(v, "-")
+		if _, ok := incidentSorts[q.Sort]; !ok {
+			return q, fmt.Errorf("cannot sort by %q, expected one of: %s", q.Sort, strings.Join(sortNames(), ", "))
+		}
+	}
+	return q, nil
+}
+
+func sortNames() []string {
+	var names []string
+	for name := range incidentSorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filter returns the incidents matching every filter of the query.
+func (q incidentQuery) filter(incidents []Incident) []Incident {
+	matched := []Incident{}
+	for _, incident := range incidents {
+		if q.matches(incident) {
+			matched = append(matched, incident)
+		}
+	}
+	return matched
+}
+
+func (q incidentQuery) matches(incident Incident) bool {
+	switch {
+	case q.Removed == "exclude" && incident.RemovedAt != "",
+		q.Removed == "only" && incident.RemovedAt == "":
+		return false
+	case q.YearFrom != 0 && incident.Year < q.YearFrom,
+		q.YearTo != 0 && (incident.Year == 0 || incident.Year > q.YearTo):
+		return false
+	case !matchesAny(q.Province, incident.Province),
+		!matchesAny(q.District, incident.District),
+		!matchesAny(q.Method, incident.Method),
+		!matchesAny(q.By, incident.By),
+		!matchesAny(q.ByCategory, incident.ByCategory),
+		!matchesAny(q.Status, incident.Status),
+		!matchesAny(q.Age, incident.Age):
+		return false
+	}
+
+	if q.AgeMin != 0 || q.AgeMax != 0 {
+		age := numericAge(incident)
+		if age < 0 || q.AgeMin != 0 && age < q.AgeMin || q.AgeMax != 0 && age > q.AgeMax {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether value matches one of the normalized filter
+// values, or whether there is no filter.
+func matchesAny(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	value = gazetteer.Normalize(value)
+	for _, f := range filter {
+		if f == value {
+			return true
+		}
+	}
+	return false
+}
+
+// numericAge returns the age of the victim in years, or -1 when the site
+// gives none or only says whether she was an adult.
+func numericAge(incident Incident) int {
+	age, err := strconv.Atoi(strings.TrimSpace(incident.Age))
+	if err != nil {
+		return -1
+	}
+	return age
+}
+
+func (q incidentQuery) sort(incidents []Incident) {
+	compare := incidentSorts[q.Sort]
+	sort.SliceStable(incidents, func(i, j int) bool {
+		c := compare(incidents[i], incidents[j])
+		if c == 0 {
+			c = incidents[i].Id - incidents[j].Id
+		}
+		if q.Descending {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	jsonFile := fs.String("json", jsonFileName, "JSON dataset to serve, reloaded when it changes")
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	fs.Parse(args)
+
+	s, err := newIncidentServer(*jsonFile)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	log.Printf("Serving %d incidents from %s on http://%s", len(s.dataset.incidents), *jsonFile, *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServeIncidents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	incidents := []Incident{
+		{Id: 1, Location: "İzmir/Buca", Date: "03/02/2023", Age: "31", Method: "Ateşli Silah", By: "Kocası"},
+		{Id: 2, Location: "Izmir", Date: "10/05/2024", Age: "Reşit", Method: "Kesici Alet", By: "Eski Kocası"},
+		{Id: 3, Location: "Ankara", Date: "01/01/2024", Age: "45", Method: "Ateşli Silah", By: "Oğlu"},
+		{Id: 4, Location: "İzmir", Date: "20/08/2024", Age: "28", Method: "Ateşli Silah", By: "Eski Sevgilisi"},
+		{Id: 5, Location: "İzmir", Date: "21/08/2024", Method: "Ateşli Silah", RemovedAt: "2024-09-01T00:00:00Z"},
+	}
+	if err := writeFile(path, func(w io.Writer) error { return writeJSON(w, incidents) }); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newIncidentServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	get := func(path string, header http.Header) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	list := func(query string) incidentPage {
+		t.Helper()
+		resp := get("/incidents?"+query, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d", query, resp.StatusCode)
+		}
+		var page incidentPage
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+	pageIds := func(page incidentPage) []int {
+		ids := []int{}
+		for _, incident := range page.Incidents {
+			ids = append(ids, incident.Id)
+		}
+		return ids
+	}
+
+	for _, test := range []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{"province=izmir&year=2024", []int{2, 4}},
+		{"year_from=2024&method=ate%C5%9Fli+silah,kesici+alet", []int{2, 3, 4}},
+		{"age_min=30&sort=-age", []int{3, 1}},
+		{"age=reşit", []int{2}},
+		{"by_category=former_partner", []int{2, 4}},
+		{"removed=only", []int{5}},
+		{"removed=include&sort=-date", []int{5, 4, 2, 3, 1}},
+	} {
+		if got := pageIds(list(test.query)); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got ids %v, want %v", test.query, got, test.want)
+		}
+	}
+
+	page := list("per_page=3&page=2")
+	if page.Total != 4 || page.Pages != 2 || !reflect.DeepEqual(pageIds(page), []int{4}) {
+		t.Errorf("second page = total %d, pages %d, ids %v", page.Total, page.Pages, pageIds(page))
+	}
+
+	for _, path := range []string{"/incidents?per_page=0", "/incidents?year=abc", "/incidents?sort=by"} {
+		if resp := get(path, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", path, resp.StatusCode)
+		}
+	}
+	if resp := get("/incidents/42", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown incident: status %d, want 404", resp.StatusCode)
+	}
+
+	resp := get("/incidents/3", nil)
+	var incident Incident
+	if err := json.NewDecoder(resp.Body).Decode(&incident); err != nil {
+		t.Fatal(err)
+	}
+	if incident.Id != 3 || incident.Province != "Ankara" || incident.Year != 2024 {
+		t.Errorf("/incidents/3 = %+v", incident)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp := get("/incidents/3", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("matching If-None-Match: status %d, want 304", resp.StatusCode)
+	}
+
+	// A new crawl replaces the file, which the server picks up
+	incidents[2].Status = "Tutuklu"
+	if err := writeFile(path, func(w io.Writer) error { return writeJSON(w, incidents) }); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	// Not before the next check is due
+	s.mu.Lock()
+	s.checked = time.Now()
+	s.checkEvery = time.Hour
+	s.mu.Unlock()
+	if resp := get("/incidents/3", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match before the reload check: status %d, want 304", resp.StatusCode)
+	}
+	s.mu.Lock()
+	s.checkEvery = 0
+	s.mu.Unlock()
+	if resp := get("/incidents/3", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("stale If-None-Match after reload: status %d, want 200", resp.StatusCode)
+	}
+}
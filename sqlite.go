@@ -0,0 +1,257 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema normalizes incidents into an incidents table, their sources
+// and lookup tables for the categorical fields.
+const sqliteSchema = `
+CREATE TABLE reasons (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+CREATE TABLE perpetrators (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+CREATE TABLE methods (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+CREATE TABLE statuses (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE);
+
+CREATE TABLE incidents (
+	id INTEGER PRIMARY KEY,
+	name TEXT,
+	fullname TEXT,
+	age TEXT,
+	location TEXT,
+	province TEXT,
+	district TEXT,
+	province_code INTEGER,
+	location_confidence REAL,
+	date TEXT,
+	date_iso TEXT,
+	year INTEGER,
+	month INTEGER,
+	reason_id INTEGER REFERENCES reasons (id),
+	by_id INTEGER REFERENCES perpetrators (id),
+	by_relation TEXT,
+	by_category TEXT,
+	protection TEXT,
+	method_id INTEGER REFERENCES methods (id),
+	status_id INTEGER REFERENCES statuses (id),
+	image TEXT,
+	url TEXT,
+	removed_at TEXT,
+	extra TEXT
+);
+
+CREATE TABLE sources (
+	incident_id INTEGER NOT NULL REFERENCES incidents (id),
+	position INTEGER NOT NULL,
+	url TEXT NOT NULL,
+	domain TEXT,
+	PRIMARY KEY (incident_id, position)
+);
+
+CREATE INDEX incidents_date_iso ON incidents (date_iso);
+CREATE INDEX incidents_year_month ON incidents (year, month);
+CREATE INDEX incidents_location ON incidents (location);
+CREATE INDEX incidents_province_district ON incidents (province, district);
+CREATE INDEX incidents_by_category ON incidents (by_category);
+CREATE INDEX sources_domain ON sources (domain);
+
+CREATE VIEW incidents_flat AS
+SELECT i.id, i.name, i.fullname, i.age, i.location, i.province, i.district,
+	i.province_code, i.location_confidence, i.date, i.date_iso, i.year, i.month,
+	r.name AS reason, p.name AS by, i.by_relation, i.by_category, i.protection, m.name AS method, s.name AS status,
+	i.image, i.url, i.removed_at, i.extra
+FROM incidents i
+LEFT JOIN reasons r ON r.id = i.reason_id
+LEFT JOIN perpetrators p ON p.id = i.by_id
+LEFT JOIN methods m ON m.id = i.method_id
+LEFT JOIN statuses s ON s.id = i.status_id;
+`
+
+// writeSQLite writes incidents to a new SQLite database at path, replacing
+// any existing file once the database is complete.
+func writeSQLite(path string, incidents []Incident) error {
+	tempPath := path + ".tmp"
+	os.Remove(tempPath)
+
+	if err := fillSQLite(tempPath, incidents); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+func fillSQLite(path string, incidents []Incident) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("cannot create schema: %w", err)
+	}
+	// The format version of the incidents is the database's user_version
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	lookups := map[string]*lookupTable{}
+	for _, table := range []string{"reasons", "perpetrators", "methods", "statuses"} {
+		if lookups[table], err = newLookupTable(tx, table); err != nil {
+			return err
+		}
+	}
+
+	insertIncident, err := tx.Prepare(`INSERT INTO incidents VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertSource, err := tx.Prepare(`INSERT INTO sources VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	for _, incident := range incidents {
+		var ids [4]interface{}
+		for i, v := range []struct{ table, name string }{
+			{"reasons", incident.Reason},
+			{"perpetrators", incident.By},
+			{"methods", incident.Method},
+			{"statuses", incident.Status},
+		} {
+			if ids[i], err = lookups[v.table].id(v.name); err != nil {
+				return err
+			}
+		}
+
+		var extra interface{}
+		if len(incident.Extra) > 0 {
+			encoded, err := json.Marshal(incident.Extra)
+			if err != nil {
+				return err
+			}
+			extra = string(encoded)
+		}
+
+		// Plate code 0 is Northern Cyprus, so only a resolved province tells
+		// whether the code is known
+		var provinceCode, confidence interface{}
+		if incident.Province != "" {
+			provinceCode, confidence = incident.ProvinceCode, incident.LocationConfidence
+		}
+
+		if _, err := insertIncident.Exec(
+			incident.Id,
+			nullable(incident.Name),
+			nullable(incident.FullName),
+			nullable(incident.Age),
+			nullable(incident.Location),
+			nullable(incident.Province),
+			nullable(incident.District),
+			provinceCode,
+			confidence,
+			nullable(incident.Date),
+			nullable(incident.DateISO),
+			nullableInt(incident.Year),
+			nullableInt(incident.Month),
+			ids[0],
+			ids[1],
+			nullable(incident.ByRelation),
+			nullable(incident.ByCategory),
+			nullable(incident.Protection),
+			ids[2],
+			ids[3],
+			nullable(incident.Image),
+			nullable(incident.Url),
+			nullable(incident.RemovedAt),
+			extra,
+		); err != nil {
+			return fmt.Errorf("incident %d: %w", incident.Id, err)
+		}
+
+		for position, source := range incident.Source {
+			if _, err := insertSource.Exec(incident.Id, position, source, nullable(sourceDomain(source))); err != nil {
+				return fmt.Errorf("incident %d: %w", incident.Id, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// lookupTable assigns ids to the distinct values of a categorical field.
+type lookupTable struct {
+	insert *sql.Stmt
+	ids    map[string]int64
+}
+
+func newLookupTable(tx *sql.Tx, table string) (*lookupTable, error) {
+	insert, err := tx.Prepare(`INSERT INTO ` + table + ` (name) VALUES (?)`)
+	if err != nil {
+		return nil, err
+	}
+	return &lookupTable{insert: insert, ids: map[string]int64{}}, nil
+}
+
+// id returns the id of name, inserting it on first use. Empty values have
+// no id.
+func (l *lookupTable) id(name string) (interface{}, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if id, ok := l.ids[name]; ok {
+		return id, nil
+	}
+
+	result, err := l.insert.Exec(name)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	l.ids[name] = id
+	return id, nil
+}
+
+// sourceDomain returns the host of a source link without "www.", or an
+// empty string for entries that aren't links.
+func sourceDomain(source string) string {
+	u, err := url.Parse(strings.TrimSpace(source))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// nullable stores empty strings as NULL.
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullableInt stores unknown numbers as NULL.
+func nullableInt(n int) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestWriteSQLite(t *testing.T) {
+	incidents := []Incident{
+		{Id: 1, Name: "A", Date: "04/04/2024", DateISO: "2024-04-04", Status: "Tutuklu", Method: "Ateşli Silah",
+			Source: []string{"https://www.hurriyet.com.tr/a", "Gazete haberi"}},
+		{Id: 2, Name: "B", Status: "Tutuklu", Location: "Girne", Province: "Kıbrıs", District: "Girne"},
+		{Id: 3, Name: "C", By: "Eski Kocası", ByRelation: "Eski Kocası", ByCategory: "former_partner"},
+	}
+
+	path := filepath.Join(t.TempDir(), "anitsayac.sqlite")
+	if err := writeSQLite(path, incidents); err != nil {
+		t.Fatal(err)
+	}
+	// Replacing an existing database
+	if err := writeSQLite(path, incidents); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`SELECT count(*) FROM incidents`, "3"},
+		{`SELECT count(*) FROM statuses`, "1"},
+		{`SELECT group_concat(id) FROM incidents_flat WHERE status = 'Tutuklu'`, "1,2"},
+		{`SELECT method FROM incidents_flat WHERE id = 1`, "Ateşli Silah"},
+		{`SELECT group_concat(coalesce(domain, 'NULL')) FROM sources WHERE incident_id = 1 ORDER BY position`, "hurriyet.com.tr,NULL"},
+		{`SELECT coalesce(province_code, 'NULL') FROM incidents WHERE id = 2`, "0"},
+		{`SELECT coalesce(province_code, 'NULL') || coalesce(date_iso, 'NULL') FROM incidents WHERE id = 3`, "NULLNULL"},
+		{`SELECT by || ', ' || by_category FROM incidents_flat WHERE id = 3`, "Eski Kocası, former_partner"},
+		{`PRAGMA user_version`, strconv.Itoa(schemaVersion)},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := db.QueryRow(tt.query).Scan(&got); err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+		} else if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
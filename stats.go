@@ -0,0 +1,352 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"AnitSayac_Scrapper/crawler/gazetteer"
+)
+
+// statsFormats are the formats statistics can be printed in.
+var statsFormats = map[string]func(io.Writer, datasetStats) error{
+	"json":     func(w io.Writer, s datasetStats) error { return writeJSON(w, s) },
+	"csv":      writeStatsCSV,
+	"markdown": writeStatsMarkdown,
+}
+
+// Labels of the values grouped together in breakdowns.
+const (
+	statsUnknown = "(unknown)"
+	statsOther   = "(other)"
+)
+
+// Whether a victim had asked for protection, from the Protection field.
+const (
+	protectionYes     = "yes"
+	protectionNo      = "no"
+	protectionUnknown = "unknown"
+)
+
+// statsCount is the number of incidents having a value.
+type statsCount struct {
+	Value string  `json:"value"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// breakdown counts the incidents per value of a field.
+type breakdown struct {
+	Name   string       `json:"name"`
+	Title  string       `json:"title"`
+	Counts []statsCount `json:"counts"`
+}
+
+// crossTab counts the incidents per pair of values of two fields. Counts
+// holds a row per value of the first field and a column per value of the
+// second.
+type crossTab struct {
+	Name    string   `json:"name"`
+	Title   string   `json:"title"`
+	Rows    []string `json:"rows"`
+	Columns []string `json:"columns"`
+	Counts  [][]int  `json:"counts"`
+}
+
+// protectionShare is the share of victims who had asked for protection,
+// among those for whom it is known.
+type protectionShare struct {
+	Yes     int     `json:"yes"`
+	No      int     `json:"no"`
+	Unknown int     `json:"unknown"`
+	Share   float64 `json:"share"`
+}
+
+type datasetStats struct {
+	Total      int             `json:"total"`
+	Protection protectionShare `json:"protection"`
+	Breakdowns []breakdown     `json:"breakdowns"`
+	CrossTabs  []crossTab      `json:"cross_tabs"`
+}
+
+// statsField extracts the value an incident is counted under.
+type statsField struct {
+	name  string
+	title string
+	value func(Incident) string
+	// ordered fields list their values in order rather than by count
+	ordered bool
+}
+
+var (
+	yearField            = statsField{"year", "Year", func(i Incident) string { return optionalInt(i.Year) }, true}
+	methodField          = statsField{"method", "Method", func(i Incident) string { return i.Method }, false}
+	byField              = statsField{"by", "Perpetrator", func(i Incident) string { return i.By }, false}
+	byCategoryField      = statsField{"by_category", "Perpetrator category", func(i Incident) string { return i.ByCategory }, false}
+	protectionOrderField = statsField{"protection_order", "Protection requested", protectionOrder, false}
+
+	// statsFields are the fields broken down by stats, in output order.
+	statsFields = []statsField{
+		yearField,
+		{"province", "Province", func(i Incident) string { return i.Province }, false},
+		methodField,
+		byField,
+		byCategoryField,
+		{"protection", "Protection", func(i Incident) string { return i.Protection }, false},
+		protectionOrderField,
+		{"status", "Perpetrator status", func(i Incident) string { return i.Status }, false},
+	}
+
+	// statsCrossTabs are the pairs of fields cross-tabulated by stats.
+	statsCrossTabs = [][2]statsField{
+		{yearField, methodField},
+		{yearField, byField},
+		{yearField, byCategoryField},
+		{yearField, protectionOrderField},
+	}
+)
+
+// protectionOrder tells whether the victim had asked for protection. Any
+// value but a plain no or an unknown one counts as a request: the site
+// records orders, complaints and shelters alike.
+func protectionOrder(incident Incident) string {
+	value := gazetteer.Normalize(incident.Protection)
+	switch {
+	case value == "", strings.HasPrefix(value, "tespited"), value == "bilinmiyor":
+		return protectionUnknown
+	// "Yol" is a typo of "Yok" on the site
+	case value == "yok", value == "yol", value == "gerceklesmemis":
+		return protectionNo
+	}
+	return protectionYes
+}
+
+// computeStats counts the incidents per field value. Breakdowns keep the
+// top values of each field and group the rest as statsOther; cross tabs
+// keep the top columns. A limit of 0 keeps every value.
+func computeStats(incidents []Incident, top, columns int) datasetStats {
+	s := datasetStats{Total: len(incidents), Breakdowns: []breakdown{}, CrossTabs: []crossTab{}}
+
+	for _, incident := range incidents {
+		switch protectionOrder(incident) {
+		case protectionYes:
+			s.Protection.Yes++
+		case protectionNo:
+			s.Protection.No++
+		default:
+			s.Protection.Unknown++
+		}
+	}
+	s.Protection.Share = share(s.Protection.Yes, s.Protection.Yes+s.Protection.No)
+
+	for _, field := range statsFields {
+		counts := countValues(incidents, field)
+		b := breakdown{Name: field.name, Title: field.title}
+		for _, value := range limitValues(counts, field, top) {
+			b.Counts = append(b.Counts, statsCount{Value: value, Count: counts[value], Share: share(counts[value], len(incidents))})
+		}
+		s.Breakdowns = append(s.Breakdowns, b)
+	}
+
+	for _, pair := range statsCrossTabs {
+		rowField, columnField := pair[0], pair[1]
+		t := crossTab{
+			Name:    rowField.name + "_" + columnField.name,
+			Title:   rowField.title + " × " + columnField.title,
+			Rows:    sortedValues(countValues(incidents, rowField), rowField),
+			Columns: limitValues(countValues(incidents, columnField), columnField, columns),
+		}
+		rowIndex := indexOf(t.Rows)
+		columnIndex := indexOf(t.Columns)
+		t.Counts = make([][]int, len(t.Rows))
+		for i := range t.Counts {
+			t.Counts[i] = make([]int, len(t.Columns))
+		}
+		for _, incident := range incidents {
+			column, ok := columnIndex[statsValue(incident, columnField)]
+			if !ok {
+				column = columnIndex[statsOther]
+			}
+			t.Counts[rowIndex[statsValue(incident, rowField)]][column]++
+		}
+		s.CrossTabs = append(s.CrossTabs, t)
+	}
+	return s
+}
+
+// statsValue returns the value an incident is counted under, statsUnknown
+// when the field is empty.
+func statsValue(incident Incident, field statsField) string {
+	if value := strings.TrimSpace(field.value(incident)); value != "" {
+		return value
+	}
+	return statsUnknown
+}
+
+func countValues(incidents []Incident, field statsField) map[string]int {
+	counts := map[string]int{}
+	for _, incident := range incidents {
+		counts[statsValue(incident, field)]++
+	}
+	return counts
+}
+
+// sortedValues lists the counted values, by decreasing count or in order
+// for ordered fields, with statsUnknown last.
+func sortedValues(counts map[string]int, field statsField) []string {
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i], values[j]
+		if (a == statsUnknown) != (b == statsUnknown) {
+			return b == statsUnknown
+		}
+		if !field.ordered && counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+	return values
+}
+
+// limitValues keeps the top values of an unordered field, folding the rest
+// into statsOther in counts. A single remaining value is kept as is.
+func limitValues(counts map[string]int, field statsField, top int) []string {
+	values := sortedValues(counts, field)
+	if field.ordered || top <= 0 || len(values) <= top+1 {
+		return values
+	}
+
+	kept := values[:top:top]
+	for _, value := range values[top:] {
+		counts[statsOther] += counts[value]
+	}
+	return append(kept, statsOther)
+}
+
+func indexOf(values []string) map[string]int {
+	index := make(map[string]int, len(values))
+	for i, value := range values {
+		index[value] = i
+	}
+	return index
+}
+
+// writeStatsCSV writes every table in long form: breakdowns as
+// table,value,,count,share and cross tabs as table,row,column,count,.
+func writeStatsCSV(w io.Writer, s datasetStats) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"table", "value", "column", "count", "share"})
+	for _, b := range s.Breakdowns {
+		for _, c := range b.Counts {
+			cw.Write([]string{b.Name, c.Value, "", strconv.Itoa(c.Count), formatShare(c.Share)})
+		}
+	}
+	for _, t := range s.CrossTabs {
+		for i, row := range t.Rows {
+			for j, column := range t.Columns {
+				cw.Write([]string{t.Name, row, column, strconv.Itoa(t.Counts[i][j]), ""})
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeStatsMarkdown writes the statistics as Markdown tables.
+func writeStatsMarkdown(w io.Writer, s datasetStats) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Statistics\n\n%d incidents. %d of the %d victims whose protection status is known had asked for protection (%s%%).\n",
+		s.Total, s.Protection.Yes, s.Protection.Yes+s.Protection.No, formatPercent(s.Protection.Share))
+
+	for _, breakdown := range s.Breakdowns {
+		fmt.Fprintf(&b, "\n### %s\n\n", breakdown.Title)
+		fmt.Fprintf(&b, "| %s | Count | Share |\n", breakdown.Title)
+		fmt.Fprintln(&b, "| --- | ---: | ---: |")
+		for _, c := range breakdown.Counts {
+			fmt.Fprintf(&b, "| %s | %d | %s%% |\n", markdownEscape(c.Value), c.Count, formatPercent(c.Share))
+		}
+	}
+
+	for _, t := range s.CrossTabs {
+		fmt.Fprintf(&b, "\n### %s\n\n", t.Title)
+		fmt.Fprintf(&b, "| %s |", strings.SplitN(t.Title, " × ", 2)[0])
+		for _, column := range t.Columns {
+			fmt.Fprintf(&b, " %s |", markdownEscape(column))
+		}
+		fmt.Fprintln(&b, " Total |")
+		fmt.Fprintf(&b, "| --- |%s\n", strings.Repeat(" ---: |", len(t.Columns)+1))
+		for i, row := range t.Rows {
+			total := 0
+			fmt.Fprintf(&b, "| %s |", markdownEscape(row))
+			for _, count := range t.Counts[i] {
+				fmt.Fprintf(&b, " %d |", count)
+				total += count
+			}
+			fmt.Fprintf(&b, " %d |\n", total)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatShare(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
+
+func formatPercent(f float64) string {
+	return strconv.FormatFloat(f*100, 'f', 1, 64)
+}
+
+// runStats prints statistics of a dataset.
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	in := fs.String("in", jsonFileName, "JSON dataset to count")
+	format := fs.String("format", "markdown", "output format: "+strings.Join(statsFormatNames(), ", "))
+	out := fs.String("out", "", "output file (default stdout)")
+	top := fs.Int("top", 0, "values listed per breakdown, the rest grouped as (other); 0 lists all")
+	columns := fs.Int("columns", 8, "columns per cross tab, the rest grouped as (other); 0 keeps all")
+	includeRemoved := fs.Bool("include-removed", false, "also count incidents no longer listed on the site")
+	fs.Parse(args)
+
+	write, ok := statsFormats[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected one of: %s", *format, strings.Join(statsFormatNames(), ", "))
+	}
+
+	incidents, err := loadIncidents(*in)
+	if err != nil {
+		return err
+	}
+	for i := range incidents {
+		incidents[i] = upgradeIncident(incidents[i])
+	}
+	if !*includeRemoved {
+		incidents = withoutRemoved(incidents)
+	}
+
+	s := computeStats(incidents, *top, *columns)
+	if *out == "" {
+		return write(os.Stdout, s)
+	}
+	return writeFile(*out, func(w io.Writer) error {
+		return write(w, s)
+	})
+}
+
+func statsFormatNames() []string {
+	names := make([]string, 0, len(statsFormats))
+	for name := range statsFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
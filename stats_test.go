@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProtectionOrder(t *testing.T) {
+	for value, want := range map[string]string{
+		"Yok":                       protectionNo,
+		"Yol":                       protectionNo,
+		"Gerçekleşmemiş":            protectionNo,
+		"Var":                       protectionYes,
+		"Var (Uzaklaştırma Kararı)": protectionYes,
+		"Korunma Altında":           protectionYes,
+		"Tespit Edilemeyen":         protectionUnknown,
+		"Tespit Edliemeyen":         protectionUnknown,
+		"Bilinmiyor":                protectionUnknown,
+		"":                          protectionUnknown,
+	} {
+		if got := protectionOrder(Incident{Protection: value}); got != want {
+			t.Errorf("protectionOrder(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	incidents := []Incident{
+		{Year: 2023, Method: "Ateşli Silah", By: "Kocası", Protection: "Yok"},
+		{Year: 2023, Method: "Ateşli Silah", By: "Kocası", Protection: "Var"},
+		{Year: 2024, Method: "Kesici Alet", By: "Oğlu", Protection: "Yok"},
+		{Year: 2024, Method: "Ateşli Silah", By: "Sevgilisi", Protection: "Tespit Edilemeyen"},
+		{Method: "Darp"},
+	}
+	s := computeStats(incidents, 1, 1)
+
+	if want := (protectionShare{Yes: 1, No: 2, Unknown: 2, Share: 1.0 / 3}); s.Protection != want {
+		t.Errorf("protection = %+v, want %+v", s.Protection, want)
+	}
+
+	counts := map[string][]statsCount{}
+	for _, b := range s.Breakdowns {
+		counts[b.Name] = b.Counts
+	}
+	if want := []statsCount{{"2023", 2, 0.4}, {"2024", 2, 0.4}, {statsUnknown, 1, 0.2}}; !reflect.DeepEqual(counts["year"], want) {
+		t.Errorf("year = %+v, want %+v", counts["year"], want)
+	}
+	// Limited to the top value, the rest grouped
+	if want := []statsCount{{"Ateşli Silah", 3, 0.6}, {statsOther, 2, 0.4}}; !reflect.DeepEqual(counts["method"], want) {
+		t.Errorf("method = %+v, want %+v", counts["method"], want)
+	}
+
+	want := crossTab{
+		Name:    "year_method",
+		Title:   "Year × Method",
+		Rows:    []string{"2023", "2024", statsUnknown},
+		Columns: []string{"Ateşli Silah", statsOther},
+		Counts:  [][]int{{2, 0}, {1, 1}, {0, 1}},
+	}
+	if !reflect.DeepEqual(s.CrossTabs[0], want) {
+		t.Errorf("cross tab = %+v, want %+v", s.CrossTabs[0], want)
+	}
+
+	var b strings.Builder
+	if err := writeStatsMarkdown(&b, s); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"1 of the 3 victims whose protection status is known had asked for protection (33.3%).",
+		"| Ateşli Silah | 3 | 60.0% |",
+		"| Year | Ateşli Silah | (other) | Total |",
+		"| 2024 | 1 | 1 | 2 |",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("markdown is missing %q:\n%s", line, b.String())
+		}
+	}
+}
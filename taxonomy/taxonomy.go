@@ -0,0 +1,142 @@
+// Package taxonomy classifies the free-text perpetrator relationship of the
+// site's "Kim tarafından öldürüldü" field.
+//
+// The embedded relations.json lists canonical relations such as "Kocası" or
+// "Eski Sevgilisi", each with the spellings seen on the site and one of the
+// Categories.
+package taxonomy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"AnitSayac_Scrapper/crawler/gazetteer"
+)
+
+//go:embed relations.json
+var relationsJSON []byte
+
+// Relationship categories, from the closest to the most distant.
+const (
+	CurrentPartner = "current_partner"
+	FormerPartner  = "former_partner"
+	Family         = "family"
+	Acquaintance   = "acquaintance"
+	Stranger       = "stranger"
+	// Unknown is a perpetrator the site records as not identified, which
+	// differs from a value the taxonomy doesn't know.
+	Unknown = "unknown"
+)
+
+// Categories lists every category in order.
+var Categories = []string{CurrentPartner, FormerPartner, Family, Acquaintance, Stranger, Unknown}
+
+// Relation is a taxonomy entry.
+type Relation struct {
+	Relation string   `json:"relation"`
+	Category string   `json:"category"`
+	Aliases  []string `json:"aliases,omitempty"`
+}
+
+// Match is the classification of a value. The zero value means the value
+// could not be classified.
+type Match struct {
+	// Relation is the canonical relation, or several joined with ", " when
+	// the value names more than one perpetrator
+	Relation string
+	Category string
+}
+
+// Mapped reports whether the value was classified.
+func (m Match) Mapped() bool {
+	return m.Category != ""
+}
+
+// Taxonomy classifies values against a list of relations.
+type Taxonomy struct {
+	relations []Relation
+	// byKey maps a normalized relation or alias to its relation.
+	byKey map[string]*Relation
+}
+
+// Default returns the taxonomy embedded in the binary.
+func Default() *Taxonomy {
+	var data struct {
+		Version   int        `json:"version"`
+		Relations []Relation `json:"relations"`
+	}
+	if err := json.Unmarshal(relationsJSON, &data); err != nil {
+		panic("taxonomy: invalid embedded data: " + err.Error())
+	}
+	return New(data.Relations)
+}
+
+// New returns a taxonomy over the given relations.
+func New(relations []Relation) *Taxonomy {
+	t := &Taxonomy{relations: relations, byKey: map[string]*Relation{}}
+	for i := range relations {
+		r := &t.relations[i]
+		t.byKey[gazetteer.Normalize(r.Relation)] = r
+		for _, alias := range r.Aliases {
+			t.byKey[gazetteer.Normalize(alias)] = r
+		}
+	}
+	return t
+}
+
+// Relations returns every relation in the order they were given.
+func (t *Taxonomy) Relations() []Relation {
+	return append([]Relation(nil), t.relations...)
+}
+
+// separators split a value naming several perpetrators, e.g. "Abisi,
+// Babası" or "Aile Meclisi - Erkek Kardeşi".
+var separators = regexp.MustCompile(`\s*(?:[,/&-]|\sve\s)\s*`)
+
+// Classify matches a value such as "Kocası", "Eski Kocası" or "Abisi,
+// Babası". A value naming several perpetrators is classified when each of
+// them is, under the category of the first one. Values that are empty or
+// unknown return the zero Match.
+func (t *Taxonomy) Classify(value string) Match {
+	// The site sometimes has non-breaking spaces between words
+	value = strings.TrimSpace(strings.ReplaceAll(value, "\u00a0", " "))
+	if value == "" {
+		return Match{}
+	}
+	if r, ok := t.byKey[gazetteer.Normalize(value)]; ok {
+		return Match{Relation: r.Relation, Category: r.Category}
+	}
+
+	var relations []string
+	var first *Relation
+	for _, part := range separators.Split(value, -1) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		r, ok := t.byKey[gazetteer.Normalize(part)]
+		if !ok {
+			return Match{}
+		}
+		if first == nil {
+			first = r
+		}
+		if !contains(relations, r.Relation) {
+			relations = append(relations, r.Relation)
+		}
+	}
+	if first == nil {
+		return Match{}
+	}
+	return Match{Relation: strings.Join(relations, ", "), Category: first.Category}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
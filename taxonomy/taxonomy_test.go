@@ -0,0 +1,98 @@
+package taxonomy
+
+import (
+	"strings"
+	"testing"
+
+	"AnitSayac_Scrapper/crawler/gazetteer"
+)
+
+func TestClassify(t *testing.T) {
+	x := Default()
+
+	tests := []struct {
+		value string
+		want  Match
+	}{
+		{"Kocası", Match{Relation: "Kocası", Category: CurrentPartner}},
+		{"Koccası", Match{Relation: "Kocası", Category: CurrentPartner}},
+		{"Dini nikahlı kocası", Match{Relation: "Dini Nikahlı Eşi", Category: CurrentPartner}},
+		{"Eskİ Kocası", Match{Relation: "Eski Kocası", Category: FormerPartner}},
+		{"Sevgilsi", Match{Relation: "Sevgilisi", Category: CurrentPartner}},
+		{"Tanıdığı Birileri", Match{Relation: "Tanıdığı Birisi", Category: Acquaintance}},
+		{"Tanımadığı Birisi", Match{Relation: "Tanımadığı Birisi", Category: Stranger}},
+		{"Tespie Edilemeyen", Match{Relation: "Tespit Edilemeyen", Category: Unknown}},
+		{"Kocasının kardeşi", Match{Relation: "Kayınbiraderi", Category: Family}},
+		// A relative of a relative is not the relative
+		{"Gelininin Babası", Match{Relation: "Dünürü", Category: Family}},
+		{"Arkadaşının Kocası", Match{Relation: "Arkadaşının Kocası", Category: Acquaintance}},
+		{"Arkadaşının Oğlu", Match{Relation: "Arkadaşının Oğlu", Category: Acquaintance}},
+		{"Kuzeninin Eski Kocası", Match{Relation: "Kuzeninin Eski Kocası", Category: Family}},
+		{"Kocasının Oğlu", Match{Relation: "Üvey Oğlu", Category: Family}},
+		{"Kocasının Kardeşinin Oğlu", Match{Relation: "Kocasının Yeğeni", Category: Family}},
+		{"Kardeşinin Eşi", Match{Relation: "Kardeşinin Eşi", Category: Family}},
+		{"Kızının Kocasının Akrabaları", Match{Relation: "Damadının Akrabası", Category: Family}},
+		{"Sevgilisinin Abisi", Match{Relation: "Sevgilisinin Abisi", Category: Acquaintance}},
+		// Buckets are only for values naming no one in particular
+		{"Kocasının Ailesi", Match{Relation: "Kocasının Akrabası", Category: Family}},
+		// Several perpetrators take the category of the first one
+		{"Abisi, Babası", Match{Relation: "Abisi, Babası", Category: Family}},
+		{"Kocası ve Akrabaları", Match{Relation: "Kocası, Akrabası", Category: CurrentPartner}},
+		{"Aile meclisi- Babası ve Erkek Kardeşi", Match{Relation: "Aile Meclisi, Babası, Erkek Kardeşi", Category: Family}},
+		{"Tanımadığı Birisi/ Polis", Match{Relation: "Tanımadığı Birisi, Polis", Category: Stranger}},
+		// Matched as a whole before being split
+		{"Seri Katil - 3 Kadın ölümü", Match{Relation: "Seri Katil", Category: Stranger}},
+		{"Kocası, Reddedilme", Match{}},
+		{"İntihar", Match{}},
+		{"", Match{}},
+	}
+
+	for _, tt := range tests {
+		if got := x.Classify(tt.value); got != tt.want {
+			t.Errorf("Classify(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultRelations(t *testing.T) {
+	categories := map[string]bool{}
+	for _, c := range Categories {
+		categories[c] = true
+	}
+
+	// Every spelling must lead to a single relation
+	seen := map[string]string{}
+	for _, r := range Default().Relations() {
+		if !categories[r.Category] {
+			t.Errorf("%s has unknown category %q", r.Relation, r.Category)
+		}
+		for _, value := range append([]string{r.Relation}, r.Aliases...) {
+			key := gazetteer.Normalize(value)
+			if other, ok := seen[key]; ok {
+				t.Errorf("%q is listed under both %s and %s", value, other, r.Relation)
+			}
+			seen[key] = r.Relation
+		}
+	}
+}
+
+// TestDefaultBuckets checks that the relations grouping unspecified
+// relatives, such as "Akrabası" or "Kocasının Akrabası", only take values
+// that are unspecific too, and not a particular relative of a relative.
+func TestDefaultBuckets(t *testing.T) {
+	unspecific := func(value string) bool {
+		key := gazetteer.Normalize(value)
+		return strings.Contains(key, "akraba") || strings.Contains(key, "aile")
+	}
+
+	for _, r := range Default().Relations() {
+		if !unspecific(r.Relation) {
+			continue
+		}
+		for _, alias := range r.Aliases {
+			if !unspecific(alias) {
+				t.Errorf("%q names a particular relative, yet is listed under %s", alias, r.Relation)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// tombstones returns the incidents of the previous dataset that are no
+// longer on the listing page, keeping their last known values and marking
+// them with the time they were first found missing. newlyRemoved counts
+// the ones that disappeared since the previous run.
+func tombstones(previous []Incident, listed map[int]bool, now time.Time) (removed []Incident, newlyRemoved int) {
+	for _, incident := range previous {
+		if listed[incident.Id] {
+			continue
+		}
+		if incident.RemovedAt == "" {
+			incident.RemovedAt = now.UTC().Format(time.RFC3339)
+			newlyRemoved++
+			log.Printf("Incident %d (%s) is no longer listed", incident.Id, incident.Name)
+		}
+		removed = append(removed, upgradeIncident(incident))
+	}
+	return removed, newlyRemoved
+}
+
+// withoutRemoved drops the tombstones of incidents removed from the site.
+func withoutRemoved(incidents []Incident) []Incident {
+	kept := make([]Incident, 0, len(incidents))
+	for _, incident := range incidents {
+		if incident.RemovedAt == "" {
+			kept = append(kept, incident)
+		}
+	}
+	return kept
+}
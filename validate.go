@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"AnitSayac_Scrapper/crawler/parser"
+)
+
+// validateFiles checks that the generated files exist, are not empty and
+// that the JSON file reads back. Their content is checked by
+// validateDataset.
+func validateFiles(jsonFile, csvFile string) bool {
+	// Check if files exist
+	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
+		log.Printf("JSON file %s does not exist", jsonFile)
+		return false
+	}
+
+	if _, err := os.Stat(csvFile); os.IsNotExist(err) {
+		log.Printf("CSV file %s does not exist", csvFile)
+		return false
+	}
+
+	// Check file sizes
+	jsonInfo, err := os.Stat(jsonFile)
+	if err != nil {
+		log.Printf("Failed to get JSON file info: %s", err)
+		return false
+	}
+
+	csvInfo, err := os.Stat(csvFile)
+	if err != nil {
+		log.Printf("Failed to get CSV file info: %s", err)
+		return false
+	}
+
+	// Files should not be empty
+	if jsonInfo.Size() == 0 {
+		log.Printf("JSON file is empty")
+		return false
+	}
+
+	if csvInfo.Size() == 0 {
+		log.Printf("CSV file is empty")
+		return false
+	}
+
+	// Basic JSON validation - try to parse
+	jsonFileContent, err := os.ReadFile(jsonFile)
+	if err != nil {
+		log.Printf("Failed to read JSON file: %s", err)
+		return false
+	}
+
+	var incidents []Incident
+	if err := json.Unmarshal(jsonFileContent, &incidents); err != nil {
+		log.Printf("Invalid JSON format: %s", err)
+		return false
+	}
+
+	return true
+}
+
+// validationRules are the thresholds a new dataset is checked against,
+// relative to the previously published one.
+type validationRules struct {
+	// MaxCountDrop is the largest allowed drop in listed incidents, as a
+	// fraction of the previous count.
+	MaxCountDrop float64
+	// MaxFillDrop is the largest allowed drop in the share of listed
+	// incidents having a value for a field.
+	MaxFillDrop float64
+	// MaxInvalidDates is the largest allowed share of listed incidents
+	// whose date is set but unparseable or in the future.
+	MaxInvalidDates float64
+}
+
+// validationCheck is the outcome of a single rule.
+type validationCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+}
+
+// fillRate is the share of listed incidents having a value for a field.
+type fillRate struct {
+	Field    string   `json:"field"`
+	Rate     float64  `json:"rate"`
+	Previous *float64 `json:"previous,omitempty"`
+}
+
+// validationReport is the machine-readable result of validating a dataset.
+// Tombstones of removed incidents are left out of every figure.
+type validationReport struct {
+	Passed        bool              `json:"passed"`
+	Count         int               `json:"count"`
+	PreviousCount int               `json:"previous_count"`
+	Checks        []validationCheck `json:"checks"`
+	FillRates     []fillRate        `json:"fill_rates"`
+}
+
+func (r *validationReport) check(name string, passed bool, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, validationCheck{Name: name, Passed: passed, Message: fmt.Sprintf(format, args...)})
+	r.Passed = r.Passed && passed
+}
+
+// Failed returns the checks that did not pass.
+func (r validationReport) Failed() []validationCheck {
+	var failed []validationCheck
+	for _, c := range r.Checks {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// validateDataset checks incidents against rules and, when there is one,
+// the previously published dataset.
+func validateDataset(incidents, previous []Incident, rules validationRules, now time.Time) validationReport {
+	listed, previousListed := withoutRemoved(incidents), withoutRemoved(previous)
+	report := validationReport{Passed: true, Count: len(listed), PreviousCount: len(previousListed), Checks: []validationCheck{}}
+
+	report.check("not_empty", len(listed) > 0, "%d listed incident(s)", len(listed))
+
+	if len(previousListed) == 0 {
+		report.check("count", true, "no previous dataset to compare with")
+	} else {
+		drop := float64(len(previousListed)-len(listed)) / float64(len(previousListed))
+		report.check("count", drop <= rules.MaxCountDrop,
+			"%d listed incident(s), previously %d (%.1f%% drop, at most %.1f%% allowed)",
+			len(listed), len(previousListed), max(drop, 0)*100, rules.MaxCountDrop*100)
+	}
+
+	seen := map[int]bool{}
+	var duplicates []int
+	for _, incident := range incidents {
+		if seen[incident.Id] {
+			duplicates = append(duplicates, incident.Id)
+		}
+		seen[incident.Id] = true
+	}
+	report.check("unique_ids", len(duplicates) == 0, "%d duplicate id(s)%s", len(duplicates), listIds(duplicates))
+
+	var invalidDates []int
+	today := now.UTC().Format("2006-01-02")
+	for _, incident := range listed {
+		if incident.Date == "" {
+			continue
+		}
+		date, _ := parser.ParseDate(incident.Date)
+		if date.Year == 0 || date.ISO > today {
+			invalidDates = append(invalidDates, incident.Id)
+		}
+	}
+	invalidShare := share(len(invalidDates), len(listed))
+	report.check("dates", invalidShare <= rules.MaxInvalidDates,
+		"%d invalid or future date(s) (%.1f%%, at most %.1f%% allowed)%s",
+		len(invalidDates), invalidShare*100, rules.MaxInvalidDates*100, listIds(invalidDates))
+
+	rates := fillRates(listed)
+	previousRates := fillRates(previousListed)
+	fillPassed := true
+	for i, rate := range rates {
+		if len(previousListed) == 0 {
+			report.FillRates = append(report.FillRates, rate)
+			continue
+		}
+		previousRate := previousRates[i].Rate
+		rate.Previous = &previousRate
+		report.FillRates = append(report.FillRates, rate)
+
+		if drop := previousRate - rate.Rate; drop > rules.MaxFillDrop {
+			fillPassed = false
+			report.check("fill_rate:"+rate.Field, false,
+				"%s filled in %.1f%% of incidents, previously %.1f%% (at most %.1f points drop allowed)",
+				rate.Field, rate.Rate*100, previousRate*100, rules.MaxFillDrop*100)
+		}
+	}
+	switch {
+	case len(previousListed) == 0:
+		report.check("fill_rates", true, "no previous dataset to compare with")
+	case fillPassed:
+		report.check("fill_rates", true, "no field dropped by more than %.1f points", rules.MaxFillDrop*100)
+	}
+	return report
+}
+
+// fillRates returns, for every data field, the share of incidents having
+// a value.
+func fillRates(incidents []Incident) []fillRate {
+	var rates []fillRate
+	filled := map[string]int{}
+	for _, incident := range incidents {
+		for _, v := range incidentValues(incident) {
+			if v.Value != "" {
+				filled[v.Field]++
+			}
+		}
+	}
+	for _, v := range incidentValues(Incident{}) {
+		if v.Field == "id" || v.Field == "removed_at" {
+			continue
+		}
+		rates = append(rates, fillRate{Field: v.Field, Rate: share(filled[v.Field], len(incidents))})
+	}
+	return rates
+}
+
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// listIds formats the first few ids for a check message.
+func listIds(ids []int) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	const shown = 10
+	s := make([]string, 0, shown)
+	for _, id := range ids[:min(len(ids), shown)] {
+		s = append(s, strconv.Itoa(id))
+	}
+	if len(ids) > shown {
+		s = append(s, "...")
+	}
+	return ": " + strings.Join(s, ", ")
+}
+
+// validationOptions controls how a new dataset is validated before it
+// replaces the published one.
+type validationOptions struct {
+	Rules validationRules
+	// Previous is the published dataset, nil if there is none.
+	Previous []Incident
+	// ReportFile receives the validation report, empty to skip it.
+	ReportFile string
+}
+
+// addValidationFlags registers the thresholds of the validation rules.
+func addValidationFlags(fs *flag.FlagSet, opts *validationOptions) {
+	fs.Float64Var(&opts.Rules.MaxCountDrop, "max-count-drop", 0.05, "largest allowed drop in listed incidents, as a fraction of the previous dataset")
+	fs.Float64Var(&opts.Rules.MaxFillDrop, "max-fill-drop", 0.1, "largest allowed drop in the fraction of incidents having a value for a field")
+	fs.Float64Var(&opts.Rules.MaxInvalidDates, "max-invalid-dates", 0.01, "largest allowed fraction of incidents with an invalid or future date")
+	fs.StringVar(&opts.ReportFile, "report", validationReportFileName, "machine-readable validation report, empty to skip it")
+}
+
+// validate runs validateDataset, writes the report and logs the failures.
+func (opts validationOptions) validate(incidents []Incident) (validationReport, error) {
+	report := validateDataset(incidents, opts.Previous, opts.Rules, time.Now())
+	if opts.ReportFile != "" {
+		if err := writeFile(opts.ReportFile, func(w io.Writer) error {
+			return writeJSON(w, report)
+		}); err != nil {
+			return report, fmt.Errorf("failed to write validation report: %w", err)
+		}
+	}
+	for _, c := range report.Failed() {
+		log.Printf("Validation failed: %s: %s", c.Name, c.Message)
+	}
+	return report, nil
+}
+
+// runValidate checks an existing data.json/data.csv pair, optionally
+// against an earlier version of the dataset.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	jsonFile := fs.String("json", jsonFileName, "JSON dataset to validate")
+	csvFile := fs.String("csv", csvFileName, "CSV dataset to validate")
+	previousFile := fs.String("previous", "", "previous JSON dataset to compare with")
+	opts := validationOptions{}
+	addValidationFlags(fs, &opts)
+	fs.Parse(args)
+
+	incidents, err := loadIncidents(*jsonFile)
+	if err != nil {
+		return err
+	}
+	if !validateFiles(*jsonFile, *csvFile) {
+		return errors.New("validation failed")
+	}
+
+	if *previousFile != "" {
+		if opts.Previous, err = loadIncidents(*previousFile); err != nil {
+			return err
+		}
+	}
+	report, err := opts.validate(incidents)
+	if err != nil {
+		return err
+	}
+	if !report.Passed {
+		return fmt.Errorf("validation failed, %d check(s) did not pass", len(report.Failed()))
+	}
+
+	log.Printf("%s and %s are valid (%d incidents)", *jsonFile, *csvFile, len(incidents))
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateDataset(t *testing.T) {
+	now := time.Date(2024, 10, 18, 0, 0, 0, 0, time.UTC)
+	rules := validationRules{MaxCountDrop: 0.2, MaxFillDrop: 0.1, MaxInvalidDates: 0}
+
+	var previous []Incident
+	for id := 1; id <= 10; id++ {
+		previous = append(previous, Incident{Id: id, Location: "İzmir", Date: "01/01/2024"})
+	}
+
+	tests := []struct {
+		name      string
+		change    func([]Incident) []Incident
+		wantFails []string
+	}{
+		{"unchanged", func(incidents []Incident) []Incident { return incidents }, nil},
+		{"count drop", func(incidents []Incident) []Incident { return incidents[3:] }, []string{"count"}},
+		{"tombstones ignored", func(incidents []Incident) []Incident {
+			incidents[0].RemovedAt = "2024-10-18T00:00:00Z"
+			incidents[0].Location = ""
+			return incidents
+		}, nil},
+		{"duplicate id", func(incidents []Incident) []Incident { return append(incidents, incidents[0]) }, []string{"unique_ids"}},
+		{"future date", func(incidents []Incident) []Incident {
+			incidents[0].Date = "01/01/2025"
+			return incidents
+		}, []string{"dates"}},
+		{"fill rate drop", func(incidents []Incident) []Incident {
+			for i := range incidents[:4] {
+				incidents[i].Location = ""
+			}
+			return incidents
+		}, []string{"fill_rate:location"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incidents := tt.change(append([]Incident(nil), previous...))
+			report := validateDataset(incidents, previous, rules, now)
+
+			var fails []string
+			for _, c := range report.Failed() {
+				fails = append(fails, c.Name)
+			}
+			if len(fails) != len(tt.wantFails) || (len(fails) > 0 && fails[0] != tt.wantFails[0]) {
+				t.Errorf("failed checks = %v, want %v", fails, tt.wantFails)
+			}
+			if report.Passed != (len(tt.wantFails) == 0) {
+				t.Errorf("Passed = %v with failed checks %v", report.Passed, fails)
+			}
+		})
+	}
+}